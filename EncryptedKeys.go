@@ -0,0 +1,225 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Encrypted key files start with a fixed header, authenticated as additional
+// data of the AEAD:
+//
+//	magic (8) | version (1) | argon2 time (4) | argon2 memory KiB (4) |
+//	argon2 threads (1) | salt (16) | nonce (24) | passphrase check (32)
+//
+// followed by the XChaCha20-Poly1305 ciphertext of the StoreKeysIncompat
+// format and a CRC-32 of everything before it. The CRC detects corruption
+// before the expensive key derivation, and the passphrase check tells a wrong
+// passphrase apart from a damaged ciphertext.
+const (
+	encryptedKeysVersion = 1
+
+	encryptedKeysSaltSize  = 16
+	encryptedKeysCheckSize = sha256.Size
+	encryptedKeysHeaderLen = len(encryptedKeysMagic) + 1 + 4 + 4 + 1 +
+		encryptedKeysSaltSize + chacha20poly1305.NonceSizeX + encryptedKeysCheckSize
+
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+
+	// argon2MaxTime and argon2MaxMemory bound the work a key file may ask
+	// for, so a damaged or crafted header cannot stall or exhaust the reader.
+	argon2MaxTime   = 4 * argon2Time
+	argon2MaxMemory = 4 * argon2Memory
+)
+
+const encryptedKeysMagic = "I2PKEYS\xe5"
+
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrCorruptKeyFile  = errors.New("corrupt key file")
+	ErrKeysEncrypted   = errors.New("keys are encrypted with a passphrase")
+)
+
+// kdfParams are the Argon2id parameters recorded in an encrypted key file.
+type kdfParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// deriveKeys stretches the passphrase into the encryption key and the
+// passphrase check value.
+func (p kdfParams) deriveKeys(passphrase, salt []byte) (key, check []byte) {
+	out := argon2.IDKey(passphrase, salt, p.time, p.memory, p.threads, chacha20poly1305.KeySize*2)
+	check32 := sha256.Sum256(out[chacha20poly1305.KeySize:])
+	return out[:chacha20poly1305.KeySize], check32[:]
+}
+
+// StoreEncryptedKeysIncompat writes keys encrypted with a passphrase.
+func StoreEncryptedKeysIncompat(k I2PKeys, w io.Writer, passphrase []byte) error {
+	log.Debug("Storing encrypted keys")
+	var plain bytes.Buffer
+	if err := StoreKeysIncompat(k, &plain); err != nil {
+		return err
+	}
+	data, err := sealKeys(plain.Bytes(), passphrase)
+	if err != nil {
+		log.Error("Error encrypting keys", "error", err)
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		log.Error("Error writing encrypted keys", "error", err)
+		return fmt.Errorf("error writing keys: %w", err)
+	}
+	return nil
+}
+
+// LoadEncryptedKeysIncompat reads keys written by StoreEncryptedKeysIncompat.
+// It returns ErrWrongPassphrase if the passphrase does not match and
+// ErrCorruptKeyFile if the data is damaged.
+func LoadEncryptedKeysIncompat(r io.Reader, passphrase []byte) (I2PKeys, error) {
+	log.Debug("Loading encrypted keys from reader")
+	data, err := io.ReadAll(r)
+	if err != nil {
+		log.Error("Error copying from reader, did not load keys", "error", err)
+		return I2PKeys{}, fmt.Errorf("error copying from reader: %w", err)
+	}
+	plain, err := openKeys(data, passphrase)
+	if err != nil {
+		log.Error("Error decrypting keys", "error", err)
+		return I2PKeys{}, err
+	}
+	return LoadKeysIncompat(bytes.NewReader(plain))
+}
+
+// StoreEncryptedKeys writes keys encrypted with a passphrase to a file
+// readable only by its owner, following the same overwrite and backup rules
+// as StoreKeysWithOptions. Only a file encrypted with the same passphrase
+// counts as already holding the keys.
+func StoreEncryptedKeys(k I2PKeys, path string, passphrase []byte, options ...StoreOption) error {
+	log.Debug("Storing encrypted keys to file", "filename", path)
+	var buf bytes.Buffer
+	if err := StoreEncryptedKeysIncompat(k, &buf, passphrase); err != nil {
+		return err
+	}
+	load := func(data []byte) (I2PKeys, error) {
+		return LoadEncryptedKeysIncompat(bytes.NewReader(data), passphrase)
+	}
+	return storeKeyData(k, path, buf.Bytes(), load, options)
+}
+
+// LoadEncryptedKeys reads keys from a file written by StoreEncryptedKeys.
+// Unlike LoadKeys it never generates keys.
+func LoadEncryptedKeys(path string, passphrase []byte) (I2PKeys, error) {
+	log.Debug("Loading encrypted keys from file", "filename", path)
+	fi, err := os.Open(path)
+	if err != nil {
+		log.Error("Error opening file", "filename", path, "error", err)
+		return I2PKeys{}, fmt.Errorf("error opening file: %w", err)
+	}
+	defer fi.Close()
+	return LoadEncryptedKeysIncompat(fi, passphrase)
+}
+
+// ChangeKeysPassphrase re-encrypts an encrypted key file with a new
+// passphrase. The file is replaced atomically, so it holds either the old or
+// the new version if interrupted.
+func ChangeKeysPassphrase(path string, oldPassphrase, newPassphrase []byte) error {
+	log.Debug("Changing key file passphrase", "filename", path)
+	k, err := LoadEncryptedKeys(path, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	// A backup would keep the keys readable with the old passphrase
+	return StoreEncryptedKeys(k, path, newPassphrase, WithOverwrite(true), WithBackups(0))
+}
+
+// sealKeys encrypts plain into the encrypted key file format.
+func sealKeys(plain, passphrase []byte) ([]byte, error) {
+	params := kdfParams{time: argon2Time, memory: argon2Memory, threads: argon2Threads}
+	salt := make([]byte, encryptedKeysSaltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key, check := params.deriveKeys(passphrase, salt)
+
+	header := make([]byte, 0, encryptedKeysHeaderLen)
+	header = append(header, encryptedKeysMagic...)
+	header = append(header, encryptedKeysVersion)
+	header = binary.BigEndian.AppendUint32(header, params.time)
+	header = binary.BigEndian.AppendUint32(header, params.memory)
+	header = append(header, params.threads)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+	header = append(header, check...)
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	out := aead.Seal(header, nonce, plain, header)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out)), nil
+}
+
+// openKeys decrypts data in the encrypted key file format.
+func openKeys(data, passphrase []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedKeysMagic)) {
+		return nil, fmt.Errorf("%w: not an encrypted key file", ErrCorruptKeyFile)
+	}
+	if len(data) < encryptedKeysHeaderLen+chacha20poly1305.Overhead+4 {
+		return nil, fmt.Errorf("%w: file too short", ErrCorruptKeyFile)
+	}
+	body, sum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptKeyFile)
+	}
+
+	header, ciphertext := body[:encryptedKeysHeaderLen], body[encryptedKeysHeaderLen:]
+	rest := header[len(encryptedKeysMagic):]
+	if rest[0] != encryptedKeysVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCorruptKeyFile, rest[0])
+	}
+	params := kdfParams{
+		time:    binary.BigEndian.Uint32(rest[1:5]),
+		memory:  binary.BigEndian.Uint32(rest[5:9]),
+		threads: rest[9],
+	}
+	if params.time == 0 || params.time > argon2MaxTime ||
+		params.threads == 0 || params.memory > argon2MaxMemory {
+		return nil, fmt.Errorf("%w: invalid key derivation parameters", ErrCorruptKeyFile)
+	}
+	rest = rest[10:]
+	salt := rest[:encryptedKeysSaltSize]
+	nonce := rest[encryptedKeysSaltSize : encryptedKeysSaltSize+chacha20poly1305.NonceSizeX]
+	storedCheck := rest[encryptedKeysSaltSize+chacha20poly1305.NonceSizeX:]
+
+	key, check := params.deriveKeys(passphrase, salt)
+	if subtle.ConstantTimeCompare(check, storedCheck) != 1 {
+		return nil, ErrWrongPassphrase
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: authentication failed", ErrCorruptKeyFile)
+	}
+	return plain, nil
+}
@@ -0,0 +1,118 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_EncryptedKeys(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	passphrase := []byte("correct horse battery staple")
+	path := filepath.Join(t.TempDir(), "keys.enc")
+
+	if err := StoreEncryptedKeys(*keys, path, passphrase); err != nil {
+		t.Fatalf("StoreEncryptedKeys failed: %v", err)
+	}
+
+	t.Run("Permissions", func(t *testing.T) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Wrong file permissions. Got %o, want 600", perm)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if bytes.Contains(content, []byte(keys.Address.Base64()[:64])) {
+			t.Error("Encrypted file contains the plain address")
+		}
+	})
+
+	t.Run("Load", func(t *testing.T) {
+		loaded, err := LoadEncryptedKeys(path, passphrase)
+		if err != nil {
+			t.Fatalf("LoadEncryptedKeys failed: %v", err)
+		}
+		if loaded.Address != keys.Address || loaded.Both != keys.Both {
+			t.Error("Loaded keys do not match the original")
+		}
+	})
+
+	t.Run("Wrong passphrase", func(t *testing.T) {
+		if _, err := LoadEncryptedKeys(path, []byte("wrong")); !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+		}
+	})
+
+	t.Run("Corruption", func(t *testing.T) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		corrupt := append([]byte(nil), content...)
+		corrupt[len(corrupt)-10] ^= 1
+		if _, err := LoadEncryptedKeysIncompat(bytes.NewReader(corrupt), passphrase); !errors.Is(err, ErrCorruptKeyFile) {
+			t.Errorf("Expected ErrCorruptKeyFile, got %v", err)
+		}
+		if _, err := LoadEncryptedKeysIncompat(bytes.NewReader(content[:len(content)/2]), passphrase); !errors.Is(err, ErrCorruptKeyFile) {
+			t.Errorf("Expected ErrCorruptKeyFile for truncated file, got %v", err)
+		}
+	})
+
+	t.Run("Inflated parameters", func(t *testing.T) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		offset := len(encryptedKeysMagic) + 1
+		for name, field := range map[string]int{"time": offset, "memory": offset + 4} {
+			inflated := append([]byte(nil), content...)
+			binary.BigEndian.PutUint32(inflated[field:], 1<<31)
+			body := inflated[:len(inflated)-4]
+			binary.BigEndian.PutUint32(inflated[len(body):], crc32.ChecksumIEEE(body))
+			if _, err := LoadEncryptedKeysIncompat(bytes.NewReader(inflated), passphrase); !errors.Is(err, ErrCorruptKeyFile) {
+				t.Errorf("Expected ErrCorruptKeyFile for inflated %s, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Plain loader", func(t *testing.T) {
+		if _, err := LoadKeys(path); !errors.Is(err, ErrKeysEncrypted) {
+			t.Errorf("Expected ErrKeysEncrypted, got %v", err)
+		}
+	})
+
+	t.Run("Change passphrase", func(t *testing.T) {
+		newPassphrase := []byte("new passphrase")
+		if err := ChangeKeysPassphrase(path, passphrase, newPassphrase); err != nil {
+			t.Fatalf("ChangeKeysPassphrase failed: %v", err)
+		}
+		if _, err := LoadEncryptedKeys(path, passphrase); !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Expected ErrWrongPassphrase for old passphrase, got %v", err)
+		}
+		loaded, err := LoadEncryptedKeys(path, newPassphrase)
+		if err != nil {
+			t.Fatalf("LoadEncryptedKeys failed: %v", err)
+		}
+		if loaded.Both != keys.Both {
+			t.Error("Loaded keys do not match the original")
+		}
+		if err := ChangeKeysPassphrase(path, passphrase, []byte("other")); !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+		}
+		if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+			t.Errorf("Expected no backup under the old passphrase, stat returned %v", err)
+		}
+	})
+}
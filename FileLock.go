@@ -0,0 +1,43 @@
+package i2pkeys
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFileSuffix is appended to a key file path to name its lock file. Lock
+// files are left in place after use: removing one while another process is
+// waiting on it would let two processes hold "the" lock at once.
+const lockFileSuffix = ".lock"
+
+// fileLock is an exclusive advisory lock held on an open lock file. The lock
+// is tied to the open file, so it also serializes goroutines of one process
+// and is released by the operating system if the process dies.
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile blocks until it holds an exclusive lock on path, creating the file
+// if needed.
+func lockFile(path string) (*fileLock, error) {
+	log.Debug("Acquiring file lock", "filename", path)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, keyFilePerm)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+	if err := lockFD(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error locking file: %w", err)
+	}
+	return &fileLock{f: f}, nil
+}
+
+// unlock releases the lock and closes the lock file.
+func (l *fileLock) unlock() error {
+	log.Debug("Releasing file lock", "filename", l.f.Name())
+	err := unlockFD(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
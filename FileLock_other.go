@@ -0,0 +1,36 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package i2pkeys
+
+import (
+	"os"
+	"sync"
+)
+
+// Platforms without flock or LockFileEx only get locking between goroutines
+// of the current process, keyed by lock file name.
+var (
+	processLocksMu sync.Mutex
+	processLocks   = make(map[string]*sync.Mutex)
+)
+
+func processLock(f *os.File) *sync.Mutex {
+	processLocksMu.Lock()
+	defer processLocksMu.Unlock()
+	mu, ok := processLocks[f.Name()]
+	if !ok {
+		mu = new(sync.Mutex)
+		processLocks[f.Name()] = mu
+	}
+	return mu
+}
+
+func lockFD(f *os.File) error {
+	processLock(f).Lock()
+	return nil
+}
+
+func unlockFD(f *os.File) error {
+	processLock(f).Unlock()
+	return nil
+}
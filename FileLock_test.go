@@ -0,0 +1,50 @@
+package i2pkeys
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_LoadKeysConcurrentGeneration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.dat")
+	var generated atomic.Int32
+	gen := func() (*I2PKeys, error) {
+		generated.Add(1)
+		return NewLocalDestination()
+	}
+
+	const workers = 8
+	results := make([]I2PKeys, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = loadOrGenerateKeys(path, gen)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("loadOrGenerateKeys failed: '%v'", errs[i])
+		}
+		if results[i] != results[0] {
+			t.Errorf("Worker %d got a different destination", i)
+		}
+	}
+	if n := generated.Load(); n != 1 {
+		t.Errorf("Expected keys to be generated once, got %d", n)
+	}
+
+	loaded, err := LoadKeys(path)
+	if err != nil {
+		t.Fatalf("LoadKeys failed: '%v'", err)
+	}
+	if loaded != results[0] {
+		t.Error("Stored keys do not match the generated keys")
+	}
+}
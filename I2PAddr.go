@@ -51,6 +51,10 @@ func NewI2PAddrFromString(addr string) (I2PAddr, error) {
 		return I2PAddr(""), err
 	}
 
+	if _, err := I2PAddr(addr).Destination(); err != nil {
+		return I2PAddr(""), err
+	}
+
 	return I2PAddr(addr), nil
 }
 
@@ -95,6 +99,7 @@ func validateBase64Encoding(addr string) error {
 }
 
 // NewI2PAddrFromBytes creates a new I2P address from a byte array.
+// The bytes must form a structurally valid destination.
 func NewI2PAddrFromBytes(addr []byte) (I2PAddr, error) {
 	// Calculate the expected encoded length to validate against string constraints
 	encodedLen := i2pB64enc.EncodedLen(len(addr))
@@ -103,6 +108,10 @@ func NewI2PAddrFromBytes(addr []byte) (I2PAddr, error) {
 			encodedLen, MinAddressLength, MaxAddressLength)
 	}
 
+	if _, err := ParseDestination(addr); err != nil {
+		return I2PAddr(""), err
+	}
+
 	encoded := make([]byte, encodedLen)
 	i2pB64enc.Encode(encoded, addr)
 	return I2PAddr(encoded), nil
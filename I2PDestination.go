@@ -0,0 +1,331 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// PublicKeyAreaSize is the size of the encryption public key area of a destination
+	PublicKeyAreaSize = 256
+
+	// SigningKeyAreaSize is the size of the signing public key area of a destination
+	SigningKeyAreaSize = 128
+
+	// KeysAndCertSize is the size of the key material preceding the certificate
+	KeysAndCertSize = PublicKeyAreaSize + SigningKeyAreaSize
+
+	// CertificateHeaderSize is the size of the certificate type and length fields
+	CertificateHeaderSize = 3
+
+	// MinDestinationSize is the size of a destination carrying an empty certificate
+	MinDestinationSize = KeysAndCertSize + CertificateHeaderSize
+)
+
+// Certificate types defined by the I2P common structures specification.
+const (
+	CertTypeNull     byte = 0
+	CertTypeHashCash byte = 1
+	CertTypeHidden   byte = 2
+	CertTypeSigned   byte = 3
+	CertTypeMultiple byte = 4
+	CertTypeKey      byte = 5
+)
+
+var ErrInvalidDestination = errors.New("invalid destination")
+
+// Certificate is the certificate section trailing the keys of a destination.
+type Certificate struct {
+	Type    byte
+	Payload []byte
+}
+
+// Length returns the length of the certificate payload.
+func (c Certificate) Length() int {
+	return len(c.Payload)
+}
+
+// Bytes returns the serialized certificate, including its type and length.
+func (c Certificate) Bytes() []byte {
+	out := make([]byte, CertificateHeaderSize+len(c.Payload))
+	out[0] = c.Type
+	binary.BigEndian.PutUint16(out[1:3], uint16(len(c.Payload)))
+	copy(out[CertificateHeaderSize:], c.Payload)
+	return out
+}
+
+// KeyCertificate is the payload of a KEY certificate. It names the signing
+// and encryption algorithms of a destination and carries the part of keys
+// too large for their area in the destination.
+type KeyCertificate struct {
+	SigType             SigType
+	EncType             EncType
+	ExcessSigningKey    []byte
+	ExcessEncryptionKey []byte
+}
+
+// ParseKeyCertificate decodes the payload of a KEY certificate. Types missing
+// from the local table are accepted, so destinations using newer algorithms
+// still parse: with an unknown signing type all excess key data is kept in
+// ExcessSigningKey, and with an unknown encryption type whatever follows the
+// signing key excess is kept in ExcessEncryptionKey.
+func ParseKeyCertificate(c Certificate) (*KeyCertificate, error) {
+	if c.Type != CertTypeKey {
+		return nil, fmt.Errorf("%w: certificate type %d is not a key certificate", ErrInvalidDestination, c.Type)
+	}
+	if c.Length() < 4 {
+		return nil, fmt.Errorf("%w: key certificate too short: %d bytes", ErrInvalidDestination, c.Length())
+	}
+
+	kc := &KeyCertificate{
+		SigType: SigType(binary.BigEndian.Uint16(c.Payload[0:2])),
+		EncType: EncType(binary.BigEndian.Uint16(c.Payload[2:4])),
+	}
+	if !kc.SigType.IsKnown() {
+		kc.ExcessSigningKey = append([]byte(nil), c.Payload[4:]...)
+		kc.ExcessEncryptionKey = []byte{}
+		return kc, nil
+	}
+
+	sigExcess := excessLength(kc.SigType.PublicKeyLen(), SigningKeyAreaSize)
+	encExcess := excessLength(kc.EncType.PublicKeyLen(), PublicKeyAreaSize)
+	want := 4 + sigExcess + encExcess
+	if c.Length() != want && (kc.EncType.IsKnown() || c.Length() < want) {
+		return nil, fmt.Errorf("%w: key certificate length %d, want %d", ErrInvalidDestination, c.Length(), want)
+	}
+	kc.ExcessSigningKey = append([]byte(nil), c.Payload[4:4+sigExcess]...)
+	kc.ExcessEncryptionKey = append([]byte(nil), c.Payload[4+sigExcess:]...)
+	return kc, nil
+}
+
+// Certificate returns the KEY certificate carrying this payload.
+func (kc KeyCertificate) Certificate() Certificate {
+	payload := make([]byte, 4, 4+len(kc.ExcessSigningKey)+len(kc.ExcessEncryptionKey))
+	binary.BigEndian.PutUint16(payload[0:2], uint16(kc.SigType))
+	binary.BigEndian.PutUint16(payload[2:4], uint16(kc.EncType))
+	payload = append(payload, kc.ExcessSigningKey...)
+	payload = append(payload, kc.ExcessEncryptionKey...)
+	return Certificate{Type: CertTypeKey, Payload: payload}
+}
+
+// Destination is the parsed form of an I2PAddr: the 256-byte public key area,
+// the 128-byte signing key area and the certificate describing how those
+// areas are used.
+type Destination struct {
+	keys    [KeysAndCertSize]byte
+	cert    Certificate
+	sigType SigType
+	encType EncType
+}
+
+// ParseDestination parses a binary destination, as returned by
+// I2PAddr.ToBytes(). The whole input must be consumed by the destination.
+func ParseDestination(data []byte) (*Destination, error) {
+	if len(data) < MinDestinationSize {
+		return nil, fmt.Errorf("%w: got %d bytes, want at least %d",
+			ErrInvalidDestination, len(data), MinDestinationSize)
+	}
+
+	d := &Destination{}
+	copy(d.keys[:], data[:KeysAndCertSize])
+
+	certType := data[KeysAndCertSize]
+	certLen := int(binary.BigEndian.Uint16(data[KeysAndCertSize+1 : MinDestinationSize]))
+	if len(data) != MinDestinationSize+certLen {
+		return nil, fmt.Errorf("%w: certificate length %d does not match %d remaining bytes",
+			ErrInvalidDestination, certLen, len(data)-MinDestinationSize)
+	}
+	d.cert = Certificate{
+		Type:    certType,
+		Payload: append([]byte(nil), data[MinDestinationSize:]...),
+	}
+
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// validate checks the certificate and records the key types it describes.
+// Destinations without a KEY certificate use DSA_SHA1 and ElGamal.
+func (d *Destination) validate() error {
+	d.sigType, d.encType = SigTypeDSASHA1, EncTypeElGamal
+	switch d.cert.Type {
+	case CertTypeNull, CertTypeHidden:
+		if d.cert.Length() != 0 {
+			return fmt.Errorf("%w: certificate type %d must be empty, got %d bytes",
+				ErrInvalidDestination, d.cert.Type, d.cert.Length())
+		}
+	case CertTypeHashCash, CertTypeSigned, CertTypeMultiple:
+	case CertTypeKey:
+		kc, err := ParseKeyCertificate(d.cert)
+		if err != nil {
+			return err
+		}
+		d.sigType, d.encType = kc.SigType, kc.EncType
+	default:
+		return fmt.Errorf("%w: unknown certificate type %d", ErrInvalidDestination, d.cert.Type)
+	}
+	return nil
+}
+
+// excessLength returns how much of a key does not fit in its area.
+func excessLength(keyLen, areaLen int) int {
+	if keyLen > areaLen {
+		return keyLen - areaLen
+	}
+	return 0
+}
+
+// SigType returns the signing algorithm of the destination, which may be a
+// type this package does not know; see SigType.IsKnown.
+func (d *Destination) SigType() SigType {
+	return d.sigType
+}
+
+// EncType returns the encryption algorithm of the destination, which may be
+// a type this package does not know; see EncType.IsKnown.
+func (d *Destination) EncType() EncType {
+	return d.encType
+}
+
+// KeyCertificate returns the decoded KEY certificate, or an error if the
+// destination carries another kind of certificate.
+func (d *Destination) KeyCertificate() (*KeyCertificate, error) {
+	return ParseKeyCertificate(d.cert)
+}
+
+// PublicKeyArea returns a copy of the 256-byte public key area.
+func (d *Destination) PublicKeyArea() []byte {
+	return append([]byte(nil), d.keys[:PublicKeyAreaSize]...)
+}
+
+// SigningKeyArea returns a copy of the 128-byte signing key area.
+func (d *Destination) SigningKeyArea() []byte {
+	return append([]byte(nil), d.keys[PublicKeyAreaSize:]...)
+}
+
+// EncryptionPublicKey returns the encryption public key, which is stored at
+// the start of the public key area, or nil if the encryption type is unknown.
+func (d *Destination) EncryptionPublicKey() []byte {
+	if !d.encType.IsKnown() {
+		return nil
+	}
+	return append([]byte(nil), d.keys[:min(d.encType.PublicKeyLen(), PublicKeyAreaSize)]...)
+}
+
+// SigningPublicKey returns the signing public key. It is right-aligned in the
+// signing key area; keys larger than the area continue in the certificate.
+// It returns nil if the signature type is unknown.
+func (d *Destination) SigningPublicKey() []byte {
+	if !d.sigType.IsKnown() {
+		return nil
+	}
+	sigLen := d.sigType.PublicKeyLen()
+	if sigLen <= SigningKeyAreaSize {
+		return append([]byte(nil), d.keys[KeysAndCertSize-sigLen:]...)
+	}
+	excess := d.cert.Payload[4 : 4+sigLen-SigningKeyAreaSize]
+	return append(d.SigningKeyArea(), excess...)
+}
+
+// Padding returns the bytes between the encryption public key and the
+// signing public key, which carry no key material. It returns nil if either
+// type is unknown.
+func (d *Destination) Padding() []byte {
+	if !d.sigType.IsKnown() || !d.encType.IsKnown() {
+		return nil
+	}
+	start := min(d.encType.PublicKeyLen(), PublicKeyAreaSize)
+	end := KeysAndCertSize - min(d.sigType.PublicKeyLen(), SigningKeyAreaSize)
+	return append([]byte(nil), d.keys[start:end]...)
+}
+
+// ValidateEncryptionKey checks that the encryption public key is a valid key
+// of its type: an ElGamal group element or a point on the named curve.
+func (d *Destination) ValidateEncryptionKey() error {
+	key := d.EncryptionPublicKey()
+	var err error
+	switch d.encType {
+	case EncTypeElGamal:
+		_, err = NewElGamalPublicKey(key)
+	case EncTypeECP256:
+		_, err = ecdh.P256().NewPublicKey(append([]byte{4}, key...))
+	case EncTypeECP384:
+		_, err = ecdh.P384().NewPublicKey(append([]byte{4}, key...))
+	case EncTypeECP521:
+		_, err = ecdh.P521().NewPublicKey(append([]byte{4}, key...))
+	case EncTypeX25519:
+		_, err = ecdh.X25519().NewPublicKey(key)
+	default:
+		return fmt.Errorf("%w: unknown encryption type %d", ErrInvalidKeyType, d.encType)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: invalid %s public key: %v", ErrInvalidDestination, d.encType, err)
+	}
+	return nil
+}
+
+// Certificate returns a copy of the destination certificate.
+func (d *Destination) Certificate() Certificate {
+	return Certificate{
+		Type:    d.cert.Type,
+		Payload: append([]byte(nil), d.cert.Payload...),
+	}
+}
+
+// Bytes returns the binary form of the destination.
+func (d *Destination) Bytes() []byte {
+	return append(d.keys[:], d.cert.Bytes()...)
+}
+
+// Addr returns the destination as an I2PAddr.
+func (d *Destination) Addr() (I2PAddr, error) {
+	return NewI2PAddrFromBytes(d.Bytes())
+}
+
+// Equal reports whether two destinations have the same binary form.
+func (d *Destination) Equal(other *Destination) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	return bytes.Equal(d.Bytes(), other.Bytes())
+}
+
+// Destination parses the address into its structured form.
+func (addr I2PAddr) Destination() (*Destination, error) {
+	data, err := addr.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return ParseDestination(data)
+}
+
+// SigType returns the signing algorithm used by the address. Types this
+// package does not know are returned along with ErrInvalidKeyType.
+func (addr I2PAddr) SigType() (SigType, error) {
+	d, err := addr.Destination()
+	if err != nil {
+		return 0, err
+	}
+	if !d.SigType().IsKnown() {
+		return d.SigType(), fmt.Errorf("%w: unknown signature type %d", ErrInvalidKeyType, d.SigType())
+	}
+	return d.SigType(), nil
+}
+
+// EncType returns the encryption algorithm used by the address. Types this
+// package does not know are returned along with ErrInvalidKeyType.
+func (addr I2PAddr) EncType() (EncType, error) {
+	d, err := addr.Destination()
+	if err != nil {
+		return 0, err
+	}
+	if !d.EncType().IsKnown() {
+		return d.EncType(), fmt.Errorf("%w: unknown encryption type %d", ErrInvalidKeyType, d.EncType())
+	}
+	return d.EncType(), nil
+}
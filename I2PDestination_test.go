@@ -0,0 +1,199 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func Test_ParseDestination(t *testing.T) {
+	addr := I2PAddr(validI2PAddrB64)
+	raw, err := addr.ToBytes()
+	if err != nil {
+		t.Fatalf("ToBytes failed: '%v'", err)
+	}
+
+	t.Run("Valid destination", func(t *testing.T) {
+		dest, err := addr.Destination()
+		if err != nil {
+			t.Fatalf("Destination failed for valid address: '%v'", err)
+		}
+		cert := dest.Certificate()
+		if cert.Type != CertTypeKey {
+			t.Errorf("Wrong certificate type. Got %d, want %d", cert.Type, CertTypeKey)
+		}
+		if cert.Length() != 4 {
+			t.Errorf("Wrong certificate length. Got %d, want 4", cert.Length())
+		}
+		if len(dest.PublicKeyArea()) != PublicKeyAreaSize {
+			t.Errorf("Wrong public key area length. Got %d, want %d", len(dest.PublicKeyArea()), PublicKeyAreaSize)
+		}
+		if len(dest.SigningPublicKey()) != 32 {
+			t.Errorf("Wrong signing public key length. Got %d, want 32", len(dest.SigningPublicKey()))
+		}
+		if len(dest.Padding()) != SigningKeyAreaSize-32 {
+			t.Errorf("Wrong padding length. Got %d, want %d", len(dest.Padding()), SigningKeyAreaSize-32)
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		dest, err := ParseDestination(raw)
+		if err != nil {
+			t.Fatalf("ParseDestination failed: '%v'", err)
+		}
+		if !bytes.Equal(dest.Bytes(), raw) {
+			t.Error("Bytes did not reproduce the parsed destination")
+		}
+		back, err := dest.Addr()
+		if err != nil {
+			t.Fatalf("Addr failed: '%v'", err)
+		}
+		if back != addr {
+			t.Errorf("Round trip changed the address. Got '%s', want '%s'", back, addr)
+		}
+	})
+
+	t.Run("Truncated destination", func(t *testing.T) {
+		_, err := ParseDestination(raw[:MinDestinationSize-1])
+		if !errors.Is(err, ErrInvalidDestination) {
+			t.Errorf("Expected ErrInvalidDestination, got '%v'", err)
+		}
+	})
+
+	t.Run("Trailing data", func(t *testing.T) {
+		_, err := ParseDestination(append(append([]byte(nil), raw...), 0))
+		if !errors.Is(err, ErrInvalidDestination) {
+			t.Errorf("Expected ErrInvalidDestination, got '%v'", err)
+		}
+	})
+
+	t.Run("Non-empty NULL certificate", func(t *testing.T) {
+		bad := append([]byte(nil), raw...)
+		bad[KeysAndCertSize] = CertTypeNull
+		_, err := ParseDestination(bad)
+		if !errors.Is(err, ErrInvalidDestination) {
+			t.Errorf("Expected ErrInvalidDestination, got '%v'", err)
+		}
+	})
+
+	t.Run("Unknown signing type", func(t *testing.T) {
+		unknown := append([]byte(nil), raw...)
+		binary.BigEndian.PutUint16(unknown[MinDestinationSize:], 12)
+		dest, err := ParseDestination(unknown)
+		if err != nil {
+			t.Fatalf("ParseDestination failed: '%v'", err)
+		}
+		if !bytes.Equal(dest.Bytes(), unknown) {
+			t.Error("Destination with an unknown type did not round-trip")
+		}
+		if dest.SigningPublicKey() != nil {
+			t.Error("Expected no signing public key for an unknown type")
+		}
+		addr, err := NewI2PAddrFromBytes(unknown)
+		if err != nil {
+			t.Fatalf("NewI2PAddrFromBytes failed: '%v'", err)
+		}
+		if _, err := NewI2PAddrFromString(addr.Base64()); err != nil {
+			t.Errorf("NewI2PAddrFromString failed: '%v'", err)
+		}
+		if sigType, err := addr.SigType(); sigType != 12 || !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected type 12 and ErrInvalidKeyType, got %d, '%v'", sigType, err)
+		}
+		if err := addr.Verify([]byte("message"), make([]byte, 64)); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType from Verify, got '%v'", err)
+		}
+	})
+
+	t.Run("Unknown types with excess key data", func(t *testing.T) {
+		kc := KeyCertificate{SigType: 0xfff0, EncType: 0xfff1, ExcessSigningKey: []byte{1, 2, 3}}
+		unknown := append(make([]byte, KeysAndCertSize), kc.Certificate().Bytes()...)
+		dest, err := ParseDestination(unknown)
+		if err != nil {
+			t.Fatalf("ParseDestination failed: '%v'", err)
+		}
+		parsed, err := dest.KeyCertificate()
+		if err != nil {
+			t.Fatalf("KeyCertificate failed: '%v'", err)
+		}
+		if !bytes.Equal(parsed.Certificate().Bytes(), kc.Certificate().Bytes()) {
+			t.Error("Opaque key certificate did not round-trip")
+		}
+		if err := dest.ValidateEncryptionKey(); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType, got '%v'", err)
+		}
+	})
+}
+
+func Test_KeyCertificate(t *testing.T) {
+	addr := I2PAddr(validI2PAddrB64)
+
+	t.Run("Address types", func(t *testing.T) {
+		sigType, err := addr.SigType()
+		if err != nil {
+			t.Fatalf("SigType failed: '%v'", err)
+		}
+		if sigType != SigTypeEd25519 {
+			t.Errorf("Wrong signature type. Got %s, want %s", sigType, SigTypeEd25519)
+		}
+		encType, err := addr.EncType()
+		if err != nil {
+			t.Fatalf("EncType failed: '%v'", err)
+		}
+		if encType != EncTypeElGamal {
+			t.Errorf("Wrong encryption type. Got %s, want %s", encType, EncTypeElGamal)
+		}
+	})
+
+	t.Run("Parse type names", func(t *testing.T) {
+		for _, s := range []string{"7", "EdDSA_SHA512_Ed25519", "eddsa_sha512_ed25519"} {
+			sigType, err := ParseSigType(s)
+			if err != nil {
+				t.Fatalf("ParseSigType(%q) failed: '%v'", s, err)
+			}
+			if sigType != SigTypeEd25519 {
+				t.Errorf("ParseSigType(%q) = %s, want %s", s, sigType, SigTypeEd25519)
+			}
+		}
+		if _, err := ParseSigType("9"); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType for reserved type, got '%v'", err)
+		}
+		encType, err := ParseEncType("ECIES_X25519")
+		if err != nil || encType != EncTypeX25519 {
+			t.Errorf("ParseEncType returned %s, '%v'", encType, err)
+		}
+	})
+
+	t.Run("Excess signing key", func(t *testing.T) {
+		kc := KeyCertificate{
+			SigType:          SigTypeECDSAP521,
+			EncType:          EncTypeElGamal,
+			ExcessSigningKey: []byte{1, 2, 3, 4},
+		}
+		raw := make([]byte, KeysAndCertSize)
+		raw[KeysAndCertSize-1] = 0xaa
+		raw = append(raw, kc.Certificate().Bytes()...)
+
+		dest, err := ParseDestination(raw)
+		if err != nil {
+			t.Fatalf("ParseDestination failed: '%v'", err)
+		}
+		if dest.SigType() != SigTypeECDSAP521 {
+			t.Errorf("Wrong signature type. Got %s, want %s", dest.SigType(), SigTypeECDSAP521)
+		}
+		spk := dest.SigningPublicKey()
+		if len(spk) != SigTypeECDSAP521.PublicKeyLen() {
+			t.Fatalf("Wrong signing public key length. Got %d, want %d", len(spk), SigTypeECDSAP521.PublicKeyLen())
+		}
+		if !bytes.Equal(spk[SigningKeyAreaSize-1:], []byte{0xaa, 1, 2, 3, 4}) {
+			t.Errorf("Excess key data not appended to signing key: %x", spk[SigningKeyAreaSize-1:])
+		}
+		if len(dest.Padding()) != 0 {
+			t.Errorf("Unexpected padding of %d bytes", len(dest.Padding()))
+		}
+
+		if _, err := ParseDestination(raw[:len(raw)-1]); err == nil {
+			t.Error("ParseDestination should have failed for missing excess key data")
+		}
+	})
+}
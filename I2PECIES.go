@@ -0,0 +1,121 @@
+package i2pkeys
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// SealedBoxOverhead is the number of bytes Seal adds to a plaintext: an
+	// ephemeral X25519 public key and the AEAD tag.
+	SealedBoxOverhead = 32 + chacha20poly1305.Overhead
+
+	sealedBoxInfo = "i2pkeys-sealed-box-v1"
+)
+
+// X25519PublicKey returns the destination's ECIES-X25519 encryption key.
+func (d *Destination) X25519PublicKey() (*ecdh.PublicKey, error) {
+	if d.EncType() != EncTypeX25519 {
+		return nil, fmt.Errorf("%w: destination uses %s encryption", ErrInvalidKeyType, d.EncType())
+	}
+	return ecdh.X25519().NewPublicKey(d.EncryptionPublicKey())
+}
+
+// X25519PublicKey returns the address's ECIES-X25519 encryption key.
+func (addr I2PAddr) X25519PublicKey() (*ecdh.PublicKey, error) {
+	d, err := addr.Destination()
+	if err != nil {
+		return nil, err
+	}
+	return d.X25519PublicKey()
+}
+
+// X25519PrivateKey returns the ECIES-X25519 encryption private key of the keys.
+func (k I2PKeys) X25519PrivateKey() (*ecdh.PrivateKey, error) {
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	if p.Destination.EncType() != EncTypeX25519 {
+		return nil, fmt.Errorf("%w: keys use %s encryption", ErrInvalidKeyType, p.Destination.EncType())
+	}
+	return ecdh.X25519().NewPrivateKey(p.EncryptionPrivateKey)
+}
+
+// Seal encrypts plaintext to the address's X25519 key, so that only the
+// holder of the matching I2PKeys can read it with Open. Each message uses a
+// fresh ephemeral key; the AEAD key is derived with HKDF-SHA256 from the
+// shared secret and both public keys, and the result is the ephemeral public
+// key followed by the ChaCha20-Poly1305 ciphertext.
+func (addr I2PAddr) Seal(random io.Reader, plaintext []byte) ([]byte, error) {
+	recipient, err := addr.X25519PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(random)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sealedBoxAEAD(shared, ephemeral.PublicKey().Bytes(), recipient.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(nil), ephemeral.PublicKey().Bytes()...)
+	return aead.Seal(out, make([]byte, chacha20poly1305.NonceSize), plaintext, nil), nil
+}
+
+// Open decrypts a message produced by Seal for the keys' address.
+func (k I2PKeys) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < SealedBoxOverhead {
+		return nil, fmt.Errorf("%w: sealed message too short", ErrDecryptionFailed)
+	}
+	priv, err := k.X25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(sealed[:32])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	shared, err := priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	aead, err := sealedBoxAEAD(shared, ephemeral.Bytes(), priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), sealed[32:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	return plaintext, nil
+}
+
+// sealedBoxAEAD derives the single-use AEAD for a sealed message from the
+// X25519 shared secret and both public keys.
+func sealedBoxAEAD(shared, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	salt := make([]byte, 0, len(ephemeral)+len(recipient))
+	salt = append(salt, ephemeral...)
+	salt = append(salt, recipient...)
+
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(sealedBoxInfo)), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
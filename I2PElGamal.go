@@ -0,0 +1,204 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+const (
+	// ElGamalBlockSize is the size of an encrypted ElGamal block.
+	ElGamalBlockSize = 514
+
+	// ElGamalMaxPlaintext is the largest payload an ElGamal block can carry.
+	// ElGamal/AES+SessionTag fills it with a session key, a pre-IV and padding.
+	ElGamalMaxPlaintext = 222
+
+	elgamalKeySize  = 256
+	elgamalHalfSize = ElGamalBlockSize / 2
+)
+
+var ErrDecryptionFailed = errors.New("decryption failed")
+
+var (
+	// elgamalPrime is the 2048-bit MODP group prime from RFC 3526, which I2P
+	// uses with generator 2 for all ElGamal keys.
+	elgamalPrime = mustHexInt("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF")
+	elgamalGenerator = big.NewInt(2)
+)
+
+// ElGamalPublicKey is a 2048-bit ElGamal public key over I2P's fixed group.
+type ElGamalPublicKey struct {
+	y *big.Int
+}
+
+// NewElGamalPublicKey decodes a 256-byte public key, rejecting values that
+// are not valid group elements.
+func NewElGamalPublicKey(raw []byte) (*ElGamalPublicKey, error) {
+	if len(raw) != elgamalKeySize {
+		return nil, fmt.Errorf("%w: ElGamal public key is %d bytes, want %d", ErrInvalidKeyType, len(raw), elgamalKeySize)
+	}
+	y := new(big.Int).SetBytes(raw)
+	pMinusOne := new(big.Int).Sub(elgamalPrime, big.NewInt(1))
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(pMinusOne) >= 0 {
+		return nil, fmt.Errorf("%w: ElGamal public key out of range", ErrInvalidKeyType)
+	}
+	return &ElGamalPublicKey{y: y}, nil
+}
+
+// Bytes returns the 256-byte encoding of the key.
+func (k *ElGamalPublicKey) Bytes() []byte {
+	return k.y.FillBytes(make([]byte, elgamalKeySize))
+}
+
+// Equal reports whether x is the same public key.
+func (k *ElGamalPublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*ElGamalPublicKey)
+	return ok && k.y.Cmp(other.y) == 0
+}
+
+// Encrypt encrypts up to 222 bytes into a 514-byte ElGamal block. The
+// plaintext is prefixed with a 0xFF byte and its SHA-256 hash, which
+// Decrypt checks.
+func (k *ElGamalPublicKey) Encrypt(random io.Reader, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > ElGamalMaxPlaintext {
+		return nil, fmt.Errorf("plaintext is %d bytes, ElGamal blocks carry at most %d", len(plaintext), ElGamalMaxPlaintext)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	hash := sha256.Sum256(plaintext)
+	d := make([]byte, 0, 1+len(hash)+len(plaintext))
+	d = append(d, 0xFF)
+	d = append(d, hash[:]...)
+	d = append(d, plaintext...)
+	m := new(big.Int).SetBytes(d)
+
+	// k is chosen uniformly from [1, p-2]
+	ephemeral, err := rand.Int(random, new(big.Int).Sub(elgamalPrime, big.NewInt(2)))
+	if err != nil {
+		return nil, err
+	}
+	ephemeral.Add(ephemeral, big.NewInt(1))
+
+	a := new(big.Int).Exp(elgamalGenerator, ephemeral, elgamalPrime)
+	b := new(big.Int).Exp(k.y, ephemeral, elgamalPrime)
+	b.Mul(b, m).Mod(b, elgamalPrime)
+
+	out := make([]byte, ElGamalBlockSize)
+	a.FillBytes(out[:elgamalHalfSize])
+	b.FillBytes(out[elgamalHalfSize:])
+	return out, nil
+}
+
+// ElGamalPrivateKey is a 2048-bit ElGamal private key over I2P's fixed group.
+// It implements crypto.Decrypter for ElGamal blocks.
+type ElGamalPrivateKey struct {
+	x      *big.Int
+	public ElGamalPublicKey
+}
+
+// NewElGamalPrivateKey decodes a 256-byte private key.
+func NewElGamalPrivateKey(raw []byte) (*ElGamalPrivateKey, error) {
+	if len(raw) != elgamalKeySize {
+		return nil, fmt.Errorf("%w: ElGamal private key is %d bytes, want %d", ErrInvalidKeyType, len(raw), elgamalKeySize)
+	}
+	x := new(big.Int).SetBytes(raw)
+	if x.Sign() <= 0 || x.Cmp(new(big.Int).Sub(elgamalPrime, big.NewInt(1))) >= 0 {
+		return nil, fmt.Errorf("%w: ElGamal private key out of range", ErrInvalidKeyType)
+	}
+	return &ElGamalPrivateKey{
+		x:      x,
+		public: ElGamalPublicKey{y: new(big.Int).Exp(elgamalGenerator, x, elgamalPrime)},
+	}, nil
+}
+
+// generateElGamalKeys returns a new key pair as raw 256-byte values.
+func generateElGamalKeys(random io.Reader) (pub, priv []byte, err error) {
+	x, err := rand.Int(random, new(big.Int).Sub(elgamalPrime, big.NewInt(2)))
+	if err != nil {
+		return nil, nil, err
+	}
+	x.Add(x, big.NewInt(1))
+	key, err := NewElGamalPrivateKey(x.FillBytes(make([]byte, elgamalKeySize)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.public.Bytes(), key.Raw(), nil
+}
+
+func (k *ElGamalPrivateKey) Type() KeyType {
+	return KeyTypeElgamal
+}
+
+func (k *ElGamalPrivateKey) Raw() []byte {
+	return k.x.FillBytes(make([]byte, elgamalKeySize))
+}
+
+func (k *ElGamalPrivateKey) Public() crypto.PublicKey {
+	return &k.public
+}
+
+// Decrypt decrypts a 514-byte ElGamal block, checking the embedded hash of
+// the plaintext. rand and opts are ignored.
+func (k *ElGamalPrivateKey) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	if len(ciphertext) != ElGamalBlockSize {
+		return nil, fmt.Errorf("%w: ElGamal block is %d bytes, want %d", ErrDecryptionFailed, len(ciphertext), ElGamalBlockSize)
+	}
+	a := new(big.Int).SetBytes(ciphertext[:elgamalHalfSize])
+	b := new(big.Int).SetBytes(ciphertext[elgamalHalfSize:])
+	if a.Cmp(elgamalPrime) >= 0 || b.Cmp(elgamalPrime) >= 0 {
+		return nil, fmt.Errorf("%w: ElGamal block out of range", ErrDecryptionFailed)
+	}
+
+	// m = b * a^(p-1-x) mod p
+	exp := new(big.Int).Sub(elgamalPrime, big.NewInt(1))
+	exp.Sub(exp, k.x)
+	m := new(big.Int).Exp(a, exp, elgamalPrime)
+	m.Mul(m, b).Mod(m, elgamalPrime)
+
+	// big.Int drops leading zeros, so the first byte is the non-zero prefix
+	d := m.Bytes()
+	if len(d) < 1+sha256.Size {
+		return nil, fmt.Errorf("%w: ElGamal block too short", ErrDecryptionFailed)
+	}
+	plaintext := d[1+sha256.Size:]
+	hash := sha256.Sum256(plaintext)
+	if !bytes.Equal(hash[:], d[1:1+sha256.Size]) {
+		return nil, fmt.Errorf("%w: ElGamal block hash mismatch", ErrDecryptionFailed)
+	}
+	return plaintext, nil
+}
+
+// ElGamalPublicKey returns the destination's ElGamal encryption key.
+func (d *Destination) ElGamalPublicKey() (*ElGamalPublicKey, error) {
+	if d.EncType() != EncTypeElGamal {
+		return nil, fmt.Errorf("%w: destination uses %s encryption", ErrInvalidKeyType, d.EncType())
+	}
+	return NewElGamalPublicKey(d.EncryptionPublicKey())
+}
+
+// ElGamalPrivateKey returns the ElGamal encryption private key of the keys.
+func (k I2PKeys) ElGamalPrivateKey() (*ElGamalPrivateKey, error) {
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	if p.Destination.EncType() != EncTypeElGamal {
+		return nil, fmt.Errorf("%w: keys use %s encryption", ErrInvalidKeyType, p.Destination.EncType())
+	}
+	return NewElGamalPrivateKey(p.EncryptionPrivateKey)
+}
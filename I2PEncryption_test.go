@@ -0,0 +1,142 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func Test_ElGamal(t *testing.T) {
+	keys, err := NewLocalDestination(WithEncType(EncTypeElGamal))
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: '%v'", err)
+	}
+	dest, err := keys.Address.Destination()
+	if err != nil {
+		t.Fatalf("Destination failed: '%v'", err)
+	}
+	if err := dest.ValidateEncryptionKey(); err != nil {
+		t.Fatalf("ValidateEncryptionKey failed: '%v'", err)
+	}
+	pub, err := dest.ElGamalPublicKey()
+	if err != nil {
+		t.Fatalf("ElGamalPublicKey failed: '%v'", err)
+	}
+	priv, err := keys.ElGamalPrivateKey()
+	if err != nil {
+		t.Fatalf("ElGamalPrivateKey failed: '%v'", err)
+	}
+	if !pub.Equal(priv.Public()) {
+		t.Fatal("Private key does not match the destination")
+	}
+	var _ crypto.Decrypter = priv
+
+	t.Run("Round trip", func(t *testing.T) {
+		for _, size := range []int{0, 32, ElGamalMaxPlaintext} {
+			plaintext := make([]byte, size)
+			rand.Read(plaintext)
+			block, err := pub.Encrypt(rand.Reader, plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt failed: '%v'", err)
+			}
+			if len(block) != ElGamalBlockSize {
+				t.Fatalf("Wrong block size. Got %d, want %d", len(block), ElGamalBlockSize)
+			}
+			got, err := priv.Decrypt(nil, block, nil)
+			if err != nil {
+				t.Fatalf("Decrypt failed: '%v'", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("Decrypt returned wrong plaintext for %d bytes", size)
+			}
+		}
+	})
+
+	t.Run("Tampered block", func(t *testing.T) {
+		block, err := pub.Encrypt(rand.Reader, []byte("test message"))
+		if err != nil {
+			t.Fatalf("Encrypt failed: '%v'", err)
+		}
+		block[ElGamalBlockSize-1] ^= 1
+		if _, err := priv.Decrypt(nil, block, nil); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("Expected ErrDecryptionFailed, got '%v'", err)
+		}
+	})
+
+	t.Run("Plaintext too long", func(t *testing.T) {
+		if _, err := pub.Encrypt(rand.Reader, make([]byte, ElGamalMaxPlaintext+1)); err == nil {
+			t.Error("Encrypt should have failed for an oversized plaintext")
+		}
+	})
+
+	t.Run("Invalid public key", func(t *testing.T) {
+		if _, err := NewElGamalPublicKey(bytes.Repeat([]byte{0xff}, 256)); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType, got '%v'", err)
+		}
+	})
+}
+
+func Test_SealedBox(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: '%v'", err)
+	}
+	message := []byte("test message")
+
+	t.Run("X25519 keys", func(t *testing.T) {
+		priv, err := keys.X25519PrivateKey()
+		if err != nil {
+			t.Fatalf("X25519PrivateKey failed: '%v'", err)
+		}
+		pub, err := keys.Address.X25519PublicKey()
+		if err != nil {
+			t.Fatalf("X25519PublicKey failed: '%v'", err)
+		}
+		if !priv.PublicKey().Equal(pub) {
+			t.Error("Private key does not match the destination")
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		sealed, err := keys.Address.Seal(rand.Reader, message)
+		if err != nil {
+			t.Fatalf("Seal failed: '%v'", err)
+		}
+		if len(sealed) != len(message)+SealedBoxOverhead {
+			t.Errorf("Wrong sealed length. Got %d, want %d", len(sealed), len(message)+SealedBoxOverhead)
+		}
+		got, err := keys.Open(sealed)
+		if err != nil {
+			t.Fatalf("Open failed: '%v'", err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("Open returned '%s', want '%s'", got, message)
+		}
+	})
+
+	t.Run("Wrong recipient", func(t *testing.T) {
+		other, err := NewLocalDestination()
+		if err != nil {
+			t.Fatalf("NewLocalDestination failed: '%v'", err)
+		}
+		sealed, err := other.Address.Seal(rand.Reader, message)
+		if err != nil {
+			t.Fatalf("Seal failed: '%v'", err)
+		}
+		if _, err := keys.Open(sealed); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("Expected ErrDecryptionFailed, got '%v'", err)
+		}
+	})
+
+	t.Run("ElGamal destination", func(t *testing.T) {
+		legacy, err := NewLocalDestination(WithEncType(EncTypeElGamal))
+		if err != nil {
+			t.Fatalf("NewLocalDestination failed: '%v'", err)
+		}
+		if _, err := legacy.Address.Seal(rand.Reader, message); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType, got '%v'", err)
+		}
+	})
+}
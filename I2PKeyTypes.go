@@ -10,8 +10,9 @@ import (
 )
 
 var (
-	ErrInvalidKeyType = errors.New("invalid key type")
-	ErrSigningFailed  = errors.New("signing operation failed")
+	ErrInvalidKeyType     = errors.New("invalid key type")
+	ErrSigningFailed      = errors.New("signing operation failed")
+	ErrVerificationFailed = errors.New("signature verification failed")
 )
 
 // KeyType represents supported key algorithms
@@ -20,6 +21,10 @@ type KeyType int
 const (
 	KeyTypeEd25519 KeyType = iota
 	KeyTypeElgamal
+	KeyTypeECDSA
+	KeyTypeDSA
+	KeyTypeRSA
+	KeyTypeRedDSA
 	// Add other key types as needed
 )
 
@@ -60,3 +65,20 @@ func (k *Ed25519SecretKey) Sign(rand io.Reader, digest []byte, opts crypto.Signe
 	}
 	return k.key.Sign(rand, digest, opts)
 }
+
+// signingDigest returns the digest to sign for signature types that hash
+// messages. If opts names no hash function, digest is the full message and is
+// hashed here, so that I2PKeys.Sign accepts messages for every type.
+// Otherwise digest must already be hashed with the type's hash function.
+func signingDigest(t SigType, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil || opts.HashFunc() == 0 {
+		return t.digest(digest), nil
+	}
+	if opts.HashFunc() != t.Hash() {
+		return nil, fmt.Errorf("%w: %s signs %v digests, got %v", ErrSigningFailed, t, t.Hash(), opts.HashFunc())
+	}
+	if len(digest) != t.Hash().Size() {
+		return nil, fmt.Errorf("%w: digest is %d bytes, want %d", ErrSigningFailed, len(digest), t.Hash().Size())
+	}
+	return digest, nil
+}
@@ -30,7 +30,7 @@ type I2PKeys struct {
 // Creates I2PKeys from an I2PAddr and a public/private keypair string (as
 // generated by String().)
 func NewKeys(addr I2PAddr, both string) I2PKeys {
-	log.WithField("addr", addr).Debug("Creating new I2PKeys")
+	log.Debug("Creating new I2PKeys", "address", addr.Base32())
 	return I2PKeys{addr, both}
 }
 
@@ -39,17 +39,17 @@ func NewKeys(addr I2PAddr, both string) I2PKeys {
 func fileExists(filename string) (bool, error) {
 	info, err := os.Stat(filename)
 	if os.IsNotExist(err) {
-		log.WithField("filename", filename).Debug("File does not exist")
+		log.Debug("File does not exist", "filename", filename)
 		return false, nil
 	} else if err != nil {
-		log.WithError(err).WithField("filename", filename).Error("Error checking file existence")
+		log.Error("Error checking file existence", "filename", filename, "error", err)
 		return false, fmt.Errorf("error checking file existence: %w", err)
 	}
 	exists := !info.IsDir()
 	if exists {
-		log.WithField("filename", filename).Debug("File exists")
+		log.Debug("File exists", "filename", filename)
 	} else {
-		log.WithField("filename", filename).Debug("File is a directory")
+		log.Debug("File is a directory", "filename", filename)
 	}
 	return !info.IsDir(), nil
 }
@@ -63,9 +63,28 @@ func (k I2PKeys) Addr() I2PAddr {
 	return k.Address
 }
 
-// Returns the public keys of the I2PKeys.
+// Returns the public key matching the signing key of the I2PKeys, as a
+// *SigningPublicKey. This is the transient key for destinations with an
+// offline signature. If the keys cannot be parsed the Address is returned,
+// which also implements Verifier.
 func (k I2PKeys) Public() crypto.PublicKey {
-	return k.Address
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		log.Debug("Could not parse keys, returning address as public key", "error", err)
+		return k.Address
+	}
+	if p.Offline != nil {
+		pub, err := NewSigningPublicKey(p.Offline.TransientSigType, p.Offline.TransientPublicKey)
+		if err != nil {
+			return k.Address
+		}
+		return pub
+	}
+	pub, err := p.Destination.Verifier()
+	if err != nil {
+		return k.Address
+	}
+	return pub
 }
 
 // Private returns the private key as a byte slice.
@@ -74,7 +93,7 @@ func (k I2PKeys) Private() []byte {
 
 	// The private key is everything after the public key in the combined string
 	fullKeys := k.String()
-	publicKey := k.Addr().String()
+	publicKey := k.Addr().Base64()
 
 	// Find where the public key ends in the full string
 	if !strings.HasPrefix(fullKeys, publicKey) {
@@ -90,7 +109,7 @@ func (k I2PKeys) Private() []byte {
 
 	n, err := i2pB64enc.Decode(dest, []byte(privateKeyB64))
 	if err != nil {
-		log.WithError(err).Error("Error decoding private key")
+		log.Error("Error decoding private key", "error", err)
 		return nil // Return nil instead of panicking
 	}
 
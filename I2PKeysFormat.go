@@ -0,0 +1,194 @@
+package i2pkeys
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces private key material when keys are formatted or logged.
+const redacted = "[REDACTED]"
+
+// redactedField is a field printed by formatRedacted. Private fields carry
+// the redacted marker as their value.
+type redactedField struct {
+	name, value string
+}
+
+// formatRedacted prints a value holding private keys in the layout I2PKeys
+// uses: {a b} for %v and %s, {A:a B:b} for %+v, goString for %#v and a
+// quoted %v for %q.
+func formatRedacted(f fmt.State, verb rune, goString string, fields ...redactedField) {
+	plain := make([]string, len(fields))
+	named := make([]string, len(fields))
+	for i, field := range fields {
+		plain[i] = field.value
+		named[i] = field.name + ":" + field.value
+	}
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		io.WriteString(f, goString)
+	case verb == 'v' && f.Flag('+'):
+		io.WriteString(f, "{"+strings.Join(named, " ")+"}")
+	case verb == 'q':
+		io.WriteString(f, strconv.Quote("{"+strings.Join(plain, " ")+"}"))
+	default:
+		io.WriteString(f, "{"+strings.Join(plain, " ")+"}")
+	}
+}
+
+// goStringRedacted returns a Go-syntax representation with every field
+// quoted.
+func goStringRedacted(typeName string, fields ...redactedField) string {
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = field.name + ":" + strconv.Quote(field.value)
+	}
+	return "i2pkeys." + typeName + "{" + strings.Join(quoted, ", ") + "}"
+}
+
+// Format implements fmt.Formatter so that printing I2PKeys with any verb
+// shows only the address, never the private keys. %v and %s print
+// {address [REDACTED]}, %+v adds field names and %#v uses GoString. Call
+// String() explicitly where the full keys are needed, such as when creating
+// a SAM session.
+func (k I2PKeys) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, k.GoString(),
+		redactedField{"Address", k.Address.String()},
+		redactedField{"Both", redacted})
+}
+
+// GoString implements fmt.GoStringer with the private keys redacted.
+func (k I2PKeys) GoString() string {
+	return goStringRedacted("I2PKeys",
+		redactedField{"Address", k.Address.Base64()},
+		redactedField{"Both", redacted})
+}
+
+// LogValue implements slog.LogValuer, logging the address and its base32
+// form with the private keys redacted.
+func (k I2PKeys) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("address", k.Address.Base32()),
+		slog.String("keys", redacted),
+	)
+}
+
+// fields are the printed fields of an offline signature section.
+func (o OfflineSignature) fields() []redactedField {
+	return []redactedField{
+		{"Expires", o.Expires.Format(time.RFC3339)},
+		{"TransientSigType", o.TransientSigType.String()},
+		{"TransientPrivateKey", redacted},
+	}
+}
+
+// Format implements fmt.Formatter, printing the expiration and transient key
+// type with the transient private key redacted.
+func (o OfflineSignature) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, o.GoString(), o.fields()...)
+}
+
+// GoString implements fmt.GoStringer with the transient private key redacted.
+func (o OfflineSignature) GoString() string {
+	return goStringRedacted("OfflineSignature", o.fields()...)
+}
+
+// LogValue implements slog.LogValuer with the transient private key
+// redacted.
+func (o OfflineSignature) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Time("expires", o.Expires),
+		slog.String("transient_sigtype", o.TransientSigType.String()),
+		slog.String("transient_key", redacted),
+	)
+}
+
+// address returns the base32 address of the destination, if it is valid.
+func (p PrivateKeyFile) address() string {
+	if p.Destination == nil {
+		return ""
+	}
+	addr, err := p.Destination.Addr()
+	if err != nil {
+		return ""
+	}
+	return addr.Base32()
+}
+
+// fields are the printed fields of a private key file.
+func (p PrivateKeyFile) fields() []redactedField {
+	offline := "<nil>"
+	if p.Offline != nil {
+		offline = fmt.Sprint(p.Offline)
+	}
+	return []redactedField{
+		{"Destination", p.address()},
+		{"EncryptionPrivateKey", redacted},
+		{"SigningPrivateKey", redacted},
+		{"Offline", offline},
+	}
+}
+
+// Format implements fmt.Formatter, printing the destination with the private
+// keys redacted.
+func (p PrivateKeyFile) Format(f fmt.State, verb rune) {
+	formatRedacted(f, verb, p.GoString(), p.fields()...)
+}
+
+// GoString implements fmt.GoStringer with the private keys redacted.
+func (p PrivateKeyFile) GoString() string {
+	return goStringRedacted("PrivateKeyFile", p.fields()...)
+}
+
+// LogValue implements slog.LogValuer, logging the destination with the
+// private keys redacted.
+func (p PrivateKeyFile) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("address", p.address()),
+		slog.String("keys", redacted),
+	}
+	if p.Offline != nil {
+		attrs = append(attrs, slog.Any("offline", p.Offline.LogValue()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Format implements fmt.Formatter, printing the key type only.
+func (k *Ed25519SecretKey) Format(f fmt.State, verb rune) {
+	formatSecretKey(f, verb, "Ed25519SecretKey", SigTypeEd25519.String())
+}
+
+// Format implements fmt.Formatter, printing the key type only.
+func (k *ECDSASecretKey) Format(f fmt.State, verb rune) {
+	formatSecretKey(f, verb, "ECDSASecretKey", k.sigType.String())
+}
+
+// Format implements fmt.Formatter, printing the key type only.
+func (k *DSASecretKey) Format(f fmt.State, verb rune) {
+	formatSecretKey(f, verb, "DSASecretKey", SigTypeDSASHA1.String())
+}
+
+// Format implements fmt.Formatter, printing the key type only.
+func (k *RSASecretKey) Format(f fmt.State, verb rune) {
+	formatSecretKey(f, verb, "RSASecretKey", k.sigType.String())
+}
+
+// Format implements fmt.Formatter, printing the key type only.
+func (k *RedDSASecretKey) Format(f fmt.State, verb rune) {
+	formatSecretKey(f, verb, "RedDSASecretKey", SigTypeRedDSA25519.String())
+}
+
+// Format implements fmt.Formatter, printing the key type only.
+func (k *ElGamalPrivateKey) Format(f fmt.State, verb rune) {
+	formatSecretKey(f, verb, "ElGamalPrivateKey", EncTypeElGamal.String())
+}
+
+// formatSecretKey prints a secret key as its type with the key redacted.
+func formatSecretKey(f fmt.State, verb rune, typeName, keyType string) {
+	fields := []redactedField{{"Type", keyType}, {"Key", redacted}}
+	formatRedacted(f, verb, "&"+goStringRedacted(typeName, fields...), fields...)
+}
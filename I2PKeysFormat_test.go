@@ -0,0 +1,114 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_KeysRedaction(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	// The private part repeats the destination, so check its tail, which
+	// encodes the private keys
+	both := keys.Both
+	private := both[len(both)-40:]
+
+	t.Run("Formatting", func(t *testing.T) {
+		for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+			out := fmt.Sprintf(format, *keys)
+			if strings.Contains(out, private) {
+				t.Errorf("%s leaked private keys: %s", format, out)
+			}
+			if !strings.Contains(out, redacted) {
+				t.Errorf("%s output is not marked as redacted: %s", format, out)
+			}
+		}
+		if out := fmt.Sprint(keys.Exportable()); strings.Contains(out, private) {
+			t.Errorf("Exportable keys leaked private keys: %s", out)
+		}
+		if keys.String() != keys.Both {
+			t.Error("String() must still return the full keys")
+		}
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		logger.Info("keys", "keys", *keys)
+		out := buf.String()
+		if strings.Contains(out, private) {
+			t.Errorf("slog leaked private keys: %s", out)
+		}
+		if !strings.Contains(out, keys.Address.Base32()) {
+			t.Errorf("slog output does not contain the address: %s", out)
+		}
+	})
+}
+
+func Test_PrivateKeyRedaction(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	p, err := keys.PrivateKeyFile()
+	if err != nil {
+		t.Fatalf("PrivateKeyFile failed: %v", err)
+	}
+	p.Offline = &OfflineSignature{
+		Expires:             time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		TransientSigType:    SigTypeEd25519,
+		TransientPublicKey:  make([]byte, 32),
+		Signature:           make([]byte, 64),
+		TransientPrivateKey: bytes.Repeat([]byte{0xab}, 32),
+	}
+	sk, err := keys.SecretKey()
+	if err != nil {
+		t.Fatalf("SecretKey failed: %v", err)
+	}
+	// Decimal and hex forms of private key bytes, as %v and %x print them
+	leaks := func(out string, key []byte) bool {
+		return strings.Contains(out, strings.Trim(fmt.Sprint(key[:8]), "[]")) || strings.Contains(out, fmt.Sprintf("%x", key[:8]))
+	}
+
+	values := map[string]any{
+		"PrivateKeyFile":   p,
+		"OfflineSignature": p.Offline,
+		"SecretKey":        sk,
+	}
+	for name, value := range values {
+		for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+			out := fmt.Sprintf(format, value)
+			for _, key := range [][]byte{p.SigningPrivateKey, p.EncryptionPrivateKey, p.Offline.TransientPrivateKey} {
+				if leaks(out, key) {
+					t.Errorf("%s with %s leaked private keys: %s", name, format, out)
+				}
+			}
+			if !strings.Contains(out, redacted) {
+				t.Errorf("%s with %s is not marked as redacted: %s", name, format, out)
+			}
+		}
+	}
+	if out := fmt.Sprintf("%+v", *p); !strings.Contains(out, keys.Address.Base32()) || leaks(out, p.SigningPrivateKey) {
+		t.Errorf("Wrong redacted output: %s", out)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("keys", "file", p, "offline", p.Offline)
+	out := buf.String()
+	for _, key := range [][]byte{p.SigningPrivateKey, p.EncryptionPrivateKey, p.Offline.TransientPrivateKey} {
+		if strings.Contains(out, base64.StdEncoding.EncodeToString(key)) {
+			t.Errorf("slog leaked private keys: %s", out)
+		}
+	}
+	if !strings.Contains(out, keys.Address.Base32()) {
+		t.Errorf("slog output does not contain the address: %s", out)
+	}
+}
@@ -1,66 +1,75 @@
 package i2pkeys
 
 import (
+	"context"
 	"fmt"
-	"net"
-	"strings"
 )
 
+const cmdLookup = "NAMING LOOKUP NAME=%s\n"
+
+// Lookup resolves a name such as example.i2p or a .b32.i2p address to a full
+// destination using the SAM bridge at DefaultSAMAddress. Use a SAMClient to
+// talk to another bridge.
 func Lookup(addr string) (*I2PAddr, error) {
-	log.WithField("addr", addr).Debug("Starting Lookup")
-	conn, err := net.Dial("tcp", "127.0.0.1:7656")
-	if err != nil {
-		log.Error("Failed to connect to SAM bridge")
+	return NewSAMClient().Lookup(addr)
+}
+
+// LookupContext is like Lookup but aborts when ctx is cancelled or its
+// deadline passes.
+func LookupContext(ctx context.Context, addr string) (*I2PAddr, error) {
+	return NewSAMClient().LookupContext(ctx, addr)
+}
+
+// Lookup resolves a name such as example.i2p or a .b32.i2p address to a full
+// destination using the SAM bridge.
+func (c *SAMClient) Lookup(name string) (*I2PAddr, error) {
+	return c.LookupContext(context.Background(), name)
+}
+
+// LookupContext is like Lookup but aborts when ctx is cancelled or its
+// deadline passes. The client timeout still applies.
+func (c *SAMClient) LookupContext(ctx context.Context, name string) (*I2PAddr, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.lookup(ctx, name)
+}
+
+func (c *SAMClient) lookup(ctx context.Context, name string) (*I2PAddr, error) {
+	c.logger.Debug("Starting Lookup", "sam", c.addr, "name", name)
+	if err := checkSAMValue("name", name); err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-	_, err = conn.Write([]byte("HELLO VERSION MIN=3.1 MAX=3.1\n"))
+	conn, err := c.connect(ctx)
 	if err != nil {
-		log.Error("Failed to write HELLO VERSION")
+		c.logger.Error("Failed to connect to SAM bridge", "sam", c.addr, "name", name, "error", err)
 		return nil, err
 	}
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
+	defer c.close(conn)
+
+	dest, err := c.namingLookup(conn, name)
 	if err != nil {
-		log.Error("Failed to read HELLO VERSION response")
+		err = contextError(ctx, err)
+		c.logger.Error("Lookup failed", "sam", c.addr, "name", name, "error", err)
 		return nil, err
 	}
-	if n < 1 {
-		log.Error("no data received")
-		return nil, fmt.Errorf("no data received")
-	}
-
-	response := string(buf[:n])
-	log.WithField("response", response).Debug("Received HELLO response")
+	c.logger.Debug("Successfully resolved I2P address", "sam", c.addr, "name", name, "address", dest.Base32())
+	return &dest, nil
+}
 
-	if strings.Contains(string(buf[:n]), "RESULT=OK") {
-		_, err = conn.Write([]byte(fmt.Sprintf("NAMING LOOKUP NAME=%s\n", addr)))
-		if err != nil {
-			log.Error("Failed to write NAMING LOOKUP command")
-			return nil, err
-		}
-		n, err = conn.Read(buf)
-		if err != nil {
-			log.Error("Failed to read NAMING LOOKUP response")
-			return nil, err
-		}
-		if n < 1 {
-			return nil, fmt.Errorf("no destination data received")
-		}
-		parts := strings.Split(string(buf[:n]), "VALUE=")
-		if len(parts) < 2 {
-			log.Error("Could not find VALUE=, maybe we couldn't find the destination?")
-			return nil, fmt.Errorf("could not find VALUE=")
-		}
-		value := parts[1]
-		addr, err := NewI2PAddrFromString(value)
-		if err != nil {
-			log.Error("Failed to parse I2P address from lookup response")
-			return nil, err
-		}
-		log.WithField("addr", addr).Debug("Successfully resolved I2P address")
-		return &addr, err
+func (c *SAMClient) namingLookup(conn *samConn, name string) (I2PAddr, error) {
+	if err := c.writeCommand(conn, fmt.Sprintf(cmdLookup, name)); err != nil {
+		return "", err
+	}
+	reply, err := c.readReply(conn, "NAMING", "REPLY")
+	if err != nil {
+		return "", err
+	}
+	if err := reply.err(); err != nil {
+		return "", fmt.Errorf("lookup of %s: %w", name, err)
+	}
+	value, ok := reply.Values["VALUE"]
+	if !ok {
+		return "", fmt.Errorf("%w: NAMING REPLY without VALUE", ErrSAMProtocol)
 	}
-	log.Error("no RESULT=OK received in HELLO response")
-	return nil, fmt.Errorf("no result received")
+	return NewI2PAddrFromString(value)
 }
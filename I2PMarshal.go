@@ -0,0 +1,236 @@
+package i2pkeys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrKeysNotExportable = errors.New("refusing to serialize private keys, use I2PKeys.Exportable")
+
+// MarshalText returns the base64 form of the address.
+func (a I2PAddr) MarshalText() ([]byte, error) {
+	return []byte(a.Base64()), nil
+}
+
+// UnmarshalText parses a base64 address with the same validation as
+// NewI2PAddrFromString. Empty text, as marshaled from the zero address,
+// decodes to the zero address.
+func (a *I2PAddr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*a = ""
+		return nil
+	}
+	addr, err := NewI2PAddrFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*a = addr
+	return nil
+}
+
+// MarshalBinary returns the binary destination.
+func (a I2PAddr) MarshalBinary() ([]byte, error) {
+	return a.ToBytes()
+}
+
+// UnmarshalBinary parses a binary destination with the same validation as
+// NewI2PAddrFromBytes. Empty data decodes to the zero address.
+func (a *I2PAddr) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		*a = ""
+		return nil
+	}
+	addr, err := NewI2PAddrFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*a = addr
+	return nil
+}
+
+// MarshalJSON encodes the address as a base64 JSON string.
+func (a I2PAddr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Base64())
+}
+
+// UnmarshalJSON decodes and validates a base64 JSON string. A JSON null
+// leaves the address unchanged.
+func (a *I2PAddr) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, a.UnmarshalText)
+}
+
+// MarshalText returns the .b32.i2p form of the hash.
+func (h I2PDestHash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText parses a base32 address. The .b32.i2p suffix may be omitted.
+func (h *I2PDestHash) UnmarshalText(text []byte) error {
+	addr := string(text)
+	if len(addr) == B32AddressLength && !strings.Contains(addr, ".") {
+		addr += B32Suffix
+	}
+	hash, err := DestHashFromString(addr)
+	if err != nil {
+		return err
+	}
+	*h = hash
+	return nil
+}
+
+// MarshalBinary returns the 32 hash bytes.
+func (h I2PDestHash) MarshalBinary() ([]byte, error) {
+	return append([]byte(nil), h[:]...), nil
+}
+
+// UnmarshalBinary reads a hash from exactly 32 bytes.
+func (h *I2PDestHash) UnmarshalBinary(data []byte) error {
+	hash, err := DestHashFromBytes(data)
+	if err != nil {
+		return err
+	}
+	*h = hash
+	return nil
+}
+
+// MarshalJSON encodes the hash as a .b32.i2p JSON string rather than an array
+// of numbers.
+func (h I2PDestHash) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.String())
+}
+
+// UnmarshalJSON decodes a base32 address JSON string. A JSON null leaves the
+// hash unchanged.
+func (h *I2PDestHash) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, h.UnmarshalText)
+}
+
+// unmarshalJSONText decodes a JSON string and passes it to unmarshal.
+func unmarshalJSONText(data []byte, unmarshal func([]byte) error) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return unmarshal([]byte(s))
+}
+
+// MarshalText refuses to serialize the private keys; see Exportable.
+func (k I2PKeys) MarshalText() ([]byte, error) {
+	return nil, ErrKeysNotExportable
+}
+
+// MarshalBinary refuses to serialize the private keys; see Exportable.
+func (k I2PKeys) MarshalBinary() ([]byte, error) {
+	return nil, ErrKeysNotExportable
+}
+
+// MarshalJSON refuses to serialize the private keys; see Exportable.
+func (k I2PKeys) MarshalJSON() ([]byte, error) {
+	return nil, ErrKeysNotExportable
+}
+
+// UnmarshalText parses keys in the format returned by String(). The address
+// is taken from the start of the keys and the private part is validated.
+// Empty text decodes to the zero keys.
+func (k *I2PKeys) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*k = I2PKeys{}
+		return nil
+	}
+	keys, err := parseKeys(string(text))
+	if err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// UnmarshalBinary parses keys from the binary private key file format. Empty
+// data decodes to the zero keys.
+func (k *I2PKeys) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		*k = I2PKeys{}
+		return nil
+	}
+	p, err := ParsePrivateKeyFile(data)
+	if err != nil {
+		return err
+	}
+	keys, err := p.I2PKeys()
+	if err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// UnmarshalJSON decodes keys from a JSON string in the format returned by
+// String(). A JSON null leaves the keys unchanged.
+func (k *I2PKeys) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, k.UnmarshalText)
+}
+
+// parseKeys splits the combined keys into address and private part. The
+// address length is determined by the certificate length in its header.
+func parseKeys(both string) (I2PKeys, error) {
+	headerLen := i2pB64enc.EncodedLen(MinDestinationSize)
+	if len(both) < headerLen {
+		return I2PKeys{}, fmt.Errorf("%w: keys too short", ErrInvalidPrivateKey)
+	}
+	header, err := i2pB64enc.DecodeString(both[:headerLen])
+	if err != nil {
+		return I2PKeys{}, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	certLen := int(header[KeysAndCertSize+1])<<8 | int(header[KeysAndCertSize+2])
+	addrLen := i2pB64enc.EncodedLen(MinDestinationSize + certLen)
+	if len(both) < addrLen {
+		return I2PKeys{}, fmt.Errorf("%w: keys too short", ErrInvalidPrivateKey)
+	}
+
+	k := I2PKeys{Address: I2PAddr(both[:addrLen]), Both: both}
+	if _, err := k.PrivateKeyFile(); err != nil {
+		return I2PKeys{}, err
+	}
+	return k, nil
+}
+
+// ExportableKeys wraps I2PKeys to opt in to serializing private key material
+// with the encoding interfaces. Obtain one from I2PKeys.Exportable.
+type ExportableKeys struct {
+	I2PKeys
+}
+
+// Exportable returns the keys wrapped so that they can be marshaled. Only use
+// it where the destination of the serialized keys is trusted.
+func (k I2PKeys) Exportable() ExportableKeys {
+	return ExportableKeys{k}
+}
+
+// MarshalText returns the keys in the format returned by String().
+func (e ExportableKeys) MarshalText() ([]byte, error) {
+	return []byte(e.Both), nil
+}
+
+// MarshalBinary returns the keys in the binary private key file format. The
+// zero keys marshal to no data.
+func (e ExportableKeys) MarshalBinary() ([]byte, error) {
+	if e.I2PKeys == (I2PKeys{}) {
+		return []byte{}, nil
+	}
+	p, err := e.PrivateKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	return p.Bytes(), nil
+}
+
+// MarshalJSON encodes the keys as a JSON string in the format returned by
+// String().
+func (e ExportableKeys) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Both)
+}
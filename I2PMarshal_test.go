@@ -0,0 +1,159 @@
+package i2pkeys
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_Marshaling(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	hash := keys.Address.DestHash()
+
+	t.Run("JSON", func(t *testing.T) {
+		type record struct {
+			Addr I2PAddr     `json:"addr"`
+			Hash I2PDestHash `json:"hash"`
+		}
+		data, err := json.Marshal(record{keys.Address, hash})
+		if err != nil {
+			t.Fatalf("Marshal failed: '%v'", err)
+		}
+		want := `{"addr":"` + keys.Address.Base64() + `","hash":"` + hash.String() + `"}`
+		if string(data) != want {
+			t.Errorf("Wrong JSON. Got %s, want %s", data, want)
+		}
+
+		var decoded record
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal failed: '%v'", err)
+		}
+		if decoded.Addr != keys.Address || decoded.Hash != hash {
+			t.Error("Decoded record does not match the original")
+		}
+	})
+
+	t.Run("JSON validation", func(t *testing.T) {
+		var addr I2PAddr
+		if err := json.Unmarshal([]byte(`"`+strings.Repeat("A", 600)+`"`), &addr); err == nil {
+			t.Error("Expected an error for an invalid address")
+		}
+		var h I2PDestHash
+		if err := json.Unmarshal([]byte(`"notahash.b32.i2p"`), &h); err == nil {
+			t.Error("Expected an error for an invalid hash")
+		}
+		if err := json.Unmarshal([]byte(`null`), &addr); err != nil || addr != "" {
+			t.Errorf("Expected null to leave the address empty, got %q, %v", addr, err)
+		}
+	})
+
+	t.Run("Zero values", func(t *testing.T) {
+		type record struct {
+			Addr I2PAddr        `json:"addr"`
+			Keys ExportableKeys `json:"keys"`
+		}
+		data, err := json.Marshal(record{})
+		if err != nil {
+			t.Fatalf("Marshal failed: '%v'", err)
+		}
+		decoded := record{Addr: keys.Address, Keys: keys.Exportable()}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal failed: '%v'", err)
+		}
+		if decoded != (record{}) {
+			t.Errorf("Zero record did not round-trip: %s", data)
+		}
+
+		var addr I2PAddr
+		bin, err := addr.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: '%v'", err)
+		}
+		addr = keys.Address
+		if err := addr.UnmarshalBinary(bin); err != nil || addr != "" {
+			t.Errorf("Zero address did not round-trip, got %q, '%v'", addr, err)
+		}
+		bin, err = ExportableKeys{}.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: '%v'", err)
+		}
+		var k I2PKeys
+		if err := k.UnmarshalBinary(bin); err != nil || k != (I2PKeys{}) {
+			t.Errorf("Zero keys did not round-trip: '%v'", err)
+		}
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		data, err := keys.Address.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: '%v'", err)
+		}
+		var addr I2PAddr
+		if err := addr.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary failed: '%v'", err)
+		}
+		if addr != keys.Address {
+			t.Error("Decoded address does not match the original")
+		}
+		if err := addr.UnmarshalBinary(data[:len(data)-1]); err == nil {
+			t.Error("Expected an error for a truncated destination")
+		}
+
+		var h I2PDestHash
+		if err := h.UnmarshalText([]byte(strings.TrimSuffix(hash.String(), B32Suffix))); err != nil {
+			t.Fatalf("UnmarshalText without suffix failed: '%v'", err)
+		}
+		if h != hash {
+			t.Error("Decoded hash does not match the original")
+		}
+	})
+
+	t.Run("Keys require opt-in", func(t *testing.T) {
+		if _, err := json.Marshal(keys); !errors.Is(err, ErrKeysNotExportable) {
+			t.Errorf("Expected ErrKeysNotExportable, got %v", err)
+		}
+		if _, err := keys.MarshalText(); !errors.Is(err, ErrKeysNotExportable) {
+			t.Errorf("Expected ErrKeysNotExportable, got %v", err)
+		}
+		if _, err := keys.MarshalBinary(); !errors.Is(err, ErrKeysNotExportable) {
+			t.Errorf("Expected ErrKeysNotExportable, got %v", err)
+		}
+	})
+
+	t.Run("Exportable keys", func(t *testing.T) {
+		data, err := json.Marshal(keys.Exportable())
+		if err != nil {
+			t.Fatalf("Marshal failed: '%v'", err)
+		}
+		var decoded I2PKeys
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal failed: '%v'", err)
+		}
+		if decoded != *keys {
+			t.Error("Decoded keys do not match the original")
+		}
+
+		bin, err := keys.Exportable().MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary failed: '%v'", err)
+		}
+		var fromBinary ExportableKeys
+		if err := fromBinary.UnmarshalBinary(bin); err != nil {
+			t.Fatalf("UnmarshalBinary failed: '%v'", err)
+		}
+		if fromBinary.I2PKeys != *keys {
+			t.Error("Decoded keys do not match the original")
+		}
+
+		if err := decoded.UnmarshalText([]byte(keys.Address.Base64())); err == nil {
+			t.Error("Expected an error for keys without a private part")
+		}
+		if err := decoded.UnmarshalText([]byte(keys.Both[:len(keys.Both)-4])); err == nil {
+			t.Error("Expected an error for truncated keys")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+)
+
+// Verifier checks signatures made by the holder of an I2P signing key.
+type Verifier interface {
+	Verify(message, sig []byte) error
+}
+
+// SigningPublicKey is a signing public key of a known type. It is returned by
+// I2PKeys.Public() and verifies signatures made by I2PKeys.Sign().
+type SigningPublicKey struct {
+	sigType SigType
+	key     []byte
+}
+
+// NewSigningPublicKey wraps a raw signing public key of the given type.
+func NewSigningPublicKey(sigType SigType, key []byte) (*SigningPublicKey, error) {
+	if !sigType.IsKnown() {
+		return nil, fmt.Errorf("%w: unknown signature type %d", ErrInvalidKeyType, sigType)
+	}
+	if len(key) != sigType.PublicKeyLen() {
+		return nil, fmt.Errorf("%w: %s public key is %d bytes, want %d",
+			ErrInvalidKeyType, sigType, len(key), sigType.PublicKeyLen())
+	}
+	return &SigningPublicKey{sigType: sigType, key: append([]byte(nil), key...)}, nil
+}
+
+// SigType returns the signature type of the key.
+func (k *SigningPublicKey) SigType() SigType {
+	return k.sigType
+}
+
+// Raw returns the key as stored in a destination.
+func (k *SigningPublicKey) Raw() []byte {
+	return append([]byte(nil), k.key...)
+}
+
+// Equal reports whether x is the same signing public key.
+func (k *SigningPublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*SigningPublicKey)
+	return ok && k.sigType == other.sigType && bytes.Equal(k.key, other.key)
+}
+
+// Verify checks sig over the full message, using the algorithm of the key's
+// signature type. It returns an error wrapping ErrVerificationFailed if the
+// signature is invalid.
+func (k *SigningPublicKey) Verify(message, sig []byte) error {
+	if len(sig) != k.sigType.SignatureLen() {
+		return fmt.Errorf("%w: %s signature is %d bytes, want %d",
+			ErrVerificationFailed, k.sigType, len(sig), k.sigType.SignatureLen())
+	}
+
+	var ok bool
+	var err error
+	switch k.sigType {
+	case SigTypeEd25519, SigTypeRedDSA25519:
+		// RedDSA signatures satisfy the same equation as Ed25519
+		ok = ed25519.Verify(ed25519.PublicKey(k.key), message, sig)
+	case SigTypeECDSAP256, SigTypeECDSAP384, SigTypeECDSAP521:
+		ok, err = verifyECDSA(k.sigType, k.key, message, sig)
+	case SigTypeDSASHA1:
+		ok, err = verifyDSA(k.key, message, sig)
+	case SigTypeRSA2048, SigTypeRSA3072, SigTypeRSA4096:
+		ok, err = verifyRSA(k.sigType, k.key, message, sig)
+	default:
+		return fmt.Errorf("%w: %s signatures are not supported", ErrInvalidKeyType, k.sigType)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: invalid %s signature", ErrVerificationFailed, k.sigType)
+	}
+	return nil
+}
+
+// Verifier returns the verifier for the destination's signing key.
+func (d *Destination) Verifier() (*SigningPublicKey, error) {
+	return NewSigningPublicKey(d.SigType(), d.SigningPublicKey())
+}
+
+// Verify checks a signature made by the destination's signing key.
+func (d *Destination) Verify(message, sig []byte) error {
+	v, err := d.Verifier()
+	if err != nil {
+		return err
+	}
+	return v.Verify(message, sig)
+}
+
+// Verify checks a signature made by the address's signing key.
+func (addr I2PAddr) Verify(message, sig []byte) error {
+	d, err := addr.Destination()
+	if err != nil {
+		return err
+	}
+	return d.Verify(message, sig)
+}
+
+// Verify checks that the transient key was authorized by the destination.
+// It does not check whether the offline signature has expired.
+func (o *OfflineSignature) Verify(d *Destination) error {
+	return d.Verify(o.signedBytes(), o.Signature)
+}
@@ -0,0 +1,115 @@
+package i2pkeys
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+var ErrNullValue = errors.New("cannot scan NULL, use a Null wrapper type")
+
+// Value stores the address as base64 text, which fits both text and binary
+// columns. The zero address is stored as empty text, which Scan reads back as
+// the zero address; use NullI2PAddr to store NULL instead.
+func (a I2PAddr) Value() (driver.Value, error) {
+	return a.Base64(), nil
+}
+
+// Scan reads an address from base64 text or from a binary destination, as
+// stored in a bytea or blob column. The address is validated.
+func (a *I2PAddr) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return a.UnmarshalText([]byte(v))
+	case []byte:
+		// A binary destination cannot be valid base64 text and vice versa:
+		// the certificate type byte of a destination is never printable.
+		if err := a.UnmarshalText(v); err == nil {
+			return nil
+		}
+		return a.UnmarshalBinary(v)
+	case nil:
+		return ErrNullValue
+	default:
+		return fmt.Errorf("cannot scan %T into I2PAddr", src)
+	}
+}
+
+// Value stores the hash as .b32.i2p text.
+func (h I2PDestHash) Value() (driver.Value, error) {
+	return h.String(), nil
+}
+
+// Scan reads a hash from a 32-byte blob or from base32 text, with or without
+// the .b32.i2p suffix.
+func (h *I2PDestHash) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return h.UnmarshalText([]byte(v))
+	case []byte:
+		if len(v) == HashSize {
+			return h.UnmarshalBinary(v)
+		}
+		return h.UnmarshalText(v)
+	case nil:
+		return ErrNullValue
+	default:
+		return fmt.Errorf("cannot scan %T into I2PDestHash", src)
+	}
+}
+
+// NullI2PAddr is an I2PAddr that may be NULL, in the manner of sql.NullString.
+// Non-NULL values are validated when scanned.
+type NullI2PAddr struct {
+	Addr  I2PAddr
+	Valid bool // Valid is true if Addr is not NULL
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *NullI2PAddr) Scan(src any) error {
+	if src == nil {
+		n.Addr, n.Valid = "", false
+		return nil
+	}
+	if err := n.Addr.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (n NullI2PAddr) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Addr.Value()
+}
+
+// NullI2PDestHash is an I2PDestHash that may be NULL, in the manner of
+// sql.NullString. Non-NULL values are validated when scanned.
+type NullI2PDestHash struct {
+	Hash  I2PDestHash
+	Valid bool // Valid is true if Hash is not NULL
+}
+
+// Scan implements the sql.Scanner interface.
+func (n *NullI2PDestHash) Scan(src any) error {
+	if src == nil {
+		n.Hash, n.Valid = I2PDestHash{}, false
+		return nil
+	}
+	if err := n.Hash.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (n NullI2PDestHash) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Hash.Value()
+}
@@ -0,0 +1,184 @@
+package i2pkeys
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// stubDriver is an in-memory database/sql driver holding one column of
+// values per data source name. Any Exec appends its argument, any Query
+// returns all stored values.
+type stubDriver struct {
+	mu     sync.Mutex
+	tables map[string][]driver.Value
+}
+
+var (
+	stubDB     = &stubDriver{tables: make(map[string][]driver.Value)}
+	stubTables atomic.Int32
+)
+
+func init() {
+	sql.Register("i2pkeys-stub", stubDB)
+}
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) {
+	return &stubConn{d: d, name: name}, nil
+}
+
+type stubConn struct {
+	d    *stubDriver
+	name string
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) { return &stubStmt{c}, nil }
+func (c *stubConn) Close() error                              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type stubStmt struct {
+	c *stubConn
+}
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return 1 }
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	s.c.d.tables[s.c.name] = append(s.c.d.tables[s.c.name], args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	return &stubRows{values: append([]driver.Value(nil), s.c.d.tables[s.c.name]...)}, nil
+}
+
+type stubRows struct {
+	values []driver.Value
+}
+
+func (r *stubRows) Columns() []string { return []string{"value"} }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+// storeAndScan stores values in a fresh stub table and returns the rows.
+func storeAndScan(t *testing.T, values ...any) *sql.Rows {
+	t.Helper()
+	db, err := sql.Open("i2pkeys-stub", fmt.Sprintf("%s-%d", t.Name(), stubTables.Add(1)))
+	if err != nil {
+		t.Fatalf("Open failed: '%v'", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, v := range values {
+		if _, err := db.Exec("INSERT", v); err != nil {
+			t.Fatalf("Exec failed: '%v'", err)
+		}
+	}
+	rows, err := db.Query("SELECT", nil)
+	if err != nil {
+		t.Fatalf("Query failed: '%v'", err)
+	}
+	t.Cleanup(func() { rows.Close() })
+	return rows
+}
+
+func Test_SQL(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	raw, err := keys.Address.ToBytes()
+	if err != nil {
+		t.Fatalf("ToBytes failed: %v", err)
+	}
+	hash := keys.Address.DestHash()
+
+	t.Run("I2PAddr", func(t *testing.T) {
+		rows := storeAndScan(t, keys.Address, raw)
+		for rows.Next() {
+			var addr I2PAddr
+			if err := rows.Scan(&addr); err != nil {
+				t.Fatalf("Scan failed: '%v'", err)
+			}
+			if addr != keys.Address {
+				t.Error("Scanned address does not match the original")
+			}
+		}
+	})
+
+	t.Run("I2PDestHash", func(t *testing.T) {
+		rows := storeAndScan(t, hash, hash[:])
+		for rows.Next() {
+			var h I2PDestHash
+			if err := rows.Scan(&h); err != nil {
+				t.Fatalf("Scan failed: '%v'", err)
+			}
+			if h != hash {
+				t.Error("Scanned hash does not match the original")
+			}
+		}
+	})
+
+	t.Run("Nullable", func(t *testing.T) {
+		rows := storeAndScan(t, NullI2PAddr{Addr: keys.Address, Valid: true}, NullI2PAddr{})
+		var got []NullI2PAddr
+		for rows.Next() {
+			var n NullI2PAddr
+			if err := rows.Scan(&n); err != nil {
+				t.Fatalf("Scan failed: '%v'", err)
+			}
+			got = append(got, n)
+		}
+		if len(got) != 2 || !got[0].Valid || got[0].Addr != keys.Address || got[1].Valid {
+			t.Errorf("Wrong nullable values: %+v", got)
+		}
+
+		hashRows := storeAndScan(t, NullI2PDestHash{})
+		for hashRows.Next() {
+			n := NullI2PDestHash{Hash: hash, Valid: true}
+			if err := hashRows.Scan(&n); err != nil {
+				t.Fatalf("Scan failed: '%v'", err)
+			}
+			if n.Valid || n.Hash != (I2PDestHash{}) {
+				t.Errorf("Expected NULL hash, got %+v", n)
+			}
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rows := storeAndScan(t, "not an address", nil)
+		rows.Next()
+		var n NullI2PAddr
+		if err := rows.Scan(&n); err == nil {
+			t.Error("Expected an error for an invalid address")
+		}
+		rows.Next()
+		var addr I2PAddr
+		if err := rows.Scan(&addr); !errors.Is(err, ErrNullValue) {
+			t.Errorf("Expected ErrNullValue, got %v", err)
+		}
+		value, err := I2PAddr("").Value()
+		if err != nil {
+			t.Fatalf("Value of the zero address failed: '%v'", err)
+		}
+		addr = keys.Address
+		if err := addr.Scan(value); err != nil || addr != "" {
+			t.Errorf("Zero address did not round-trip, got %q, '%v'", addr, err)
+		}
+	})
+}
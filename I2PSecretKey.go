@@ -10,14 +10,49 @@ import (
 	"io"
 )
 
-// SecretKey returns a type-safe secret key implementation
+// SecretKey returns a type-safe secret key implementation for the signing
+// private key, or the transient key of destinations with an offline signature.
 func (k I2PKeys) SecretKey() (SecretKeyProvider, error) {
-	rawKey := k.Private()
-	if len(rawKey) != ed25519.PrivateKeySize {
-		return nil, fmt.Errorf("%w: expected Ed25519 key", ErrInvalidKeyType)
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
 	}
+	return newSecretKeyProvider(p.SigType(), p.SigningKey(), p.signingPublicKey())
+}
 
-	return NewEd25519SecretKey(ed25519.PrivateKey(rawKey))
+// newSecretKeyProvider wraps a raw signing private key of the given type.
+// pub is the matching public key, checked against private keys that embed it.
+func newSecretKeyProvider(sigType SigType, raw, pub []byte) (SecretKeyProvider, error) {
+	if len(raw) != sigType.PrivateKeyLen() {
+		return nil, fmt.Errorf("%w: %s private key is %d bytes, want %d",
+			ErrInvalidKeyType, sigType, len(raw), sigType.PrivateKeyLen())
+	}
+	switch sigType {
+	case SigTypeEd25519:
+		return NewEd25519SecretKey(ed25519.NewKeyFromSeed(raw))
+	case SigTypeECDSAP256, SigTypeECDSAP384, SigTypeECDSAP521:
+		key, err := newECDSAPrivateKey(sigType, raw)
+		if err != nil {
+			return nil, err
+		}
+		return NewECDSASecretKey(key)
+	case SigTypeDSASHA1:
+		key, err := newDSAPrivateKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		return NewDSASecretKey(key)
+	case SigTypeRSA2048, SigTypeRSA3072, SigTypeRSA4096:
+		key, err := newRSAPrivateKey(sigType, raw, pub)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSASecretKey(key)
+	case SigTypeRedDSA25519:
+		return NewRedDSASecretKey(raw)
+	default:
+		return nil, fmt.Errorf("%w: %s signing keys are not supported", ErrInvalidKeyType, sigType)
+	}
 }
 
 // PrivateKey returns the crypto.PrivateKey interface implementation
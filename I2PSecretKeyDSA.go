@@ -0,0 +1,128 @@
+package i2pkeys
+
+import (
+	"crypto"
+	"crypto/dsa"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// dsaParameters is the fixed 1024-bit DSA group used by I2P for DSA_SHA1.
+var dsaParameters = dsa.Parameters{
+	P: mustHexInt("9C05B2AA960D9B97B8931963C9CC9E8C3026E9B8ED92FAD0A69CC886D5BF8015" +
+		"FCADAE31A0AD18FAB3F01B00A358DE237655C4964AFAA2B337E96AD316B9FB1C" +
+		"C564B5AEC5B69A9FF6C3E4548707FEF8503D91DD8602E867E6D35D2235C1869C" +
+		"E2479C3B9D5401DE04E0727FB33D6511285D4CF29538D9E3B6051F5B22CC1C93"),
+	Q: mustHexInt("A5DFC28FEF4CA1E286744CD8EED9D29D684046B7"),
+	G: mustHexInt("0C1F4D27D40093B429E962D7223824E0BBC47E7C832A39236FC683AF84889581" +
+		"075FF9082ED32353D4374D7301CDA1D23C431F4698599DDA02451824FF369752" +
+		"593647CC3DDC197DE985E43D136CDCFC6BD5409CD2F450821142A5E6F8EB1C3A" +
+		"B5D0484B8129FCF17BCE4F7F33321C3CB3DBB14A905E7B2B3E93BE4708CBCC82"),
+}
+
+// mustHexInt parses a hexadecimal group constant.
+func mustHexInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("i2pkeys: invalid group constant")
+	}
+	return n
+}
+
+// DSASecretKey provides a type-safe wrapper for legacy DSA_SHA1 keys over
+// I2P's fixed group. Signatures are 40 bytes: r and s, 20 bytes each.
+type DSASecretKey struct {
+	key *dsa.PrivateKey
+}
+
+// NewDSASecretKey wraps a DSA key, which must use I2P's group parameters.
+func NewDSASecretKey(key *dsa.PrivateKey) (*DSASecretKey, error) {
+	if key == nil || key.P == nil || key.Q == nil || key.G == nil || key.X == nil || key.Y == nil {
+		return nil, fmt.Errorf("%w: incomplete DSA key", ErrInvalidKeyType)
+	}
+	if key.P.Cmp(dsaParameters.P) != 0 || key.Q.Cmp(dsaParameters.Q) != 0 || key.G.Cmp(dsaParameters.G) != 0 {
+		return nil, fmt.Errorf("%w: DSA key does not use the I2P group", ErrInvalidKeyType)
+	}
+	return &DSASecretKey{key: key}, nil
+}
+
+// newDSAPrivateKey decodes a raw 20-byte private exponent.
+func newDSAPrivateKey(raw []byte) (*dsa.PrivateKey, error) {
+	x := new(big.Int).SetBytes(raw)
+	if x.Sign() <= 0 || x.Cmp(dsaParameters.Q) >= 0 {
+		return nil, fmt.Errorf("%w: DSA private key out of range", ErrInvalidKeyType)
+	}
+	return &dsa.PrivateKey{
+		PublicKey: dsa.PublicKey{
+			Parameters: dsaParameters,
+			Y:          new(big.Int).Exp(dsaParameters.G, x, dsaParameters.P),
+		},
+		X: x,
+	}, nil
+}
+
+// parseDSAPublicKey decodes a raw 128-byte public value.
+func parseDSAPublicKey(raw []byte) (*dsa.PublicKey, error) {
+	y := new(big.Int).SetBytes(raw)
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(dsaParameters.P) >= 0 {
+		return nil, fmt.Errorf("%w: DSA public key out of range", ErrInvalidKeyType)
+	}
+	return &dsa.PublicKey{Parameters: dsaParameters, Y: y}, nil
+}
+
+// generateDSAKeys returns a new key pair as the raw public and private values.
+func generateDSAKeys(rand io.Reader) (pub, priv []byte, err error) {
+	key := &dsa.PrivateKey{PublicKey: dsa.PublicKey{Parameters: dsaParameters}}
+	if err := dsa.GenerateKey(key, rand); err != nil {
+		return nil, nil, err
+	}
+	pub = key.Y.FillBytes(make([]byte, SigTypeDSASHA1.PublicKeyLen()))
+	priv = key.X.FillBytes(make([]byte, SigTypeDSASHA1.PrivateKeyLen()))
+	return pub, priv, nil
+}
+
+func (k *DSASecretKey) Type() KeyType {
+	return KeyTypeDSA
+}
+
+// SigType returns the I2P signature type of the key.
+func (k *DSASecretKey) SigType() SigType {
+	return SigTypeDSASHA1
+}
+
+func (k *DSASecretKey) Raw() []byte {
+	return k.key.X.FillBytes(make([]byte, SigTypeDSASHA1.PrivateKeyLen()))
+}
+
+func (k *DSASecretKey) Public() crypto.PublicKey {
+	return &k.key.PublicKey
+}
+
+func (k *DSASecretKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if k == nil || k.key == nil {
+		return nil, fmt.Errorf("%w: invalid key state", ErrInvalidKeyType)
+	}
+	hashed, err := signingDigest(SigTypeDSASHA1, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := dsa.Sign(rand, k.key, hashed)
+	if err != nil {
+		return nil, err
+	}
+	half := SigTypeDSASHA1.SignatureLen() / 2
+	return append(r.FillBytes(make([]byte, half)), s.FillBytes(make([]byte, half))...), nil
+}
+
+// verifyDSA checks an r||s signature over message.
+func verifyDSA(key, message, sig []byte) (bool, error) {
+	pub, err := parseDSAPublicKey(key)
+	if err != nil {
+		return false, err
+	}
+	half := len(sig) / 2
+	r := new(big.Int).SetBytes(sig[:half])
+	s := new(big.Int).SetBytes(sig[half:])
+	return dsa.Verify(pub, SigTypeDSASHA1.digest(message), r, s), nil
+}
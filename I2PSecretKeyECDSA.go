@@ -0,0 +1,156 @@
+package i2pkeys
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ecdsaCurve returns the curves used by the ECDSA signature types.
+func ecdsaCurve(t SigType) (elliptic.Curve, ecdh.Curve, error) {
+	switch t {
+	case SigTypeECDSAP256:
+		return elliptic.P256(), ecdh.P256(), nil
+	case SigTypeECDSAP384:
+		return elliptic.P384(), ecdh.P384(), nil
+	case SigTypeECDSAP521:
+		return elliptic.P521(), ecdh.P521(), nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s is not an ECDSA type", ErrInvalidKeyType, t)
+	}
+}
+
+// ecdsaSigType returns the signature type using the given curve.
+func ecdsaSigType(curve elliptic.Curve) (SigType, error) {
+	switch curve {
+	case elliptic.P256():
+		return SigTypeECDSAP256, nil
+	case elliptic.P384():
+		return SigTypeECDSAP384, nil
+	case elliptic.P521():
+		return SigTypeECDSAP521, nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported ECDSA curve", ErrInvalidKeyType)
+	}
+}
+
+// ECDSASecretKey provides a type-safe wrapper for ECDSA keys on the P-256,
+// P-384 and P-521 curves. Signatures use I2P's fixed-length r||s encoding
+// rather than ASN.1.
+type ECDSASecretKey struct {
+	sigType SigType
+	key     *ecdsa.PrivateKey
+}
+
+func NewECDSASecretKey(key *ecdsa.PrivateKey) (*ECDSASecretKey, error) {
+	if key == nil {
+		return nil, fmt.Errorf("%w: nil ECDSA key", ErrInvalidKeyType)
+	}
+	sigType, err := ecdsaSigType(key.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return &ECDSASecretKey{sigType: sigType, key: key}, nil
+}
+
+// newECDSAPrivateKey decodes a raw big-endian private scalar.
+func newECDSAPrivateKey(t SigType, raw []byte) (*ecdsa.PrivateKey, error) {
+	_, ecdhCurve, err := ecdsaCurve(t)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := ecdhCurve.NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyType, err)
+	}
+	pub, err := parseECDSAPublicKey(t, priv.PublicKey().Bytes()[1:])
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PrivateKey{PublicKey: *pub, D: new(big.Int).SetBytes(raw)}, nil
+}
+
+// parseECDSAPublicKey decodes a public key stored as X||Y.
+func parseECDSAPublicKey(t SigType, raw []byte) (*ecdsa.PublicKey, error) {
+	curve, ecdhCurve, err := ecdsaCurve(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != t.PublicKeyLen() {
+		return nil, fmt.Errorf("%w: %s public key is %d bytes, want %d", ErrInvalidKeyType, t, len(raw), t.PublicKeyLen())
+	}
+	// Reject points that are not on the curve.
+	if _, err := ecdhCurve.NewPublicKey(append([]byte{4}, raw...)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyType, err)
+	}
+	half := len(raw) / 2
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(raw[:half]),
+		Y:     new(big.Int).SetBytes(raw[half:]),
+	}, nil
+}
+
+// generateECDSAKeys returns a new key pair encoded as X||Y and the scalar.
+func generateECDSAKeys(rand io.Reader, t SigType) (pub, priv []byte, err error) {
+	curve, _, err := ecdsaCurve(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := ecdsa.GenerateKey(curve, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	half := t.PublicKeyLen() / 2
+	pub = append(key.X.FillBytes(make([]byte, half)), key.Y.FillBytes(make([]byte, half))...)
+	return pub, key.D.FillBytes(make([]byte, t.PrivateKeyLen())), nil
+}
+
+func (k *ECDSASecretKey) Type() KeyType {
+	return KeyTypeECDSA
+}
+
+// SigType returns the I2P signature type of the key.
+func (k *ECDSASecretKey) SigType() SigType {
+	return k.sigType
+}
+
+func (k *ECDSASecretKey) Raw() []byte {
+	return k.key.D.FillBytes(make([]byte, k.sigType.PrivateKeyLen()))
+}
+
+func (k *ECDSASecretKey) Public() crypto.PublicKey {
+	return &k.key.PublicKey
+}
+
+func (k *ECDSASecretKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if k == nil || k.key == nil {
+		return nil, fmt.Errorf("%w: invalid key state", ErrInvalidKeyType)
+	}
+	hashed, err := signingDigest(k.sigType, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := ecdsa.Sign(rand, k.key, hashed)
+	if err != nil {
+		return nil, err
+	}
+	half := k.sigType.SignatureLen() / 2
+	return append(r.FillBytes(make([]byte, half)), s.FillBytes(make([]byte, half))...), nil
+}
+
+// verifyECDSA checks an r||s signature over message.
+func verifyECDSA(t SigType, key, message, sig []byte) (bool, error) {
+	pub, err := parseECDSAPublicKey(t, key)
+	if err != nil {
+		return false, err
+	}
+	half := len(sig) / 2
+	r := new(big.Int).SetBytes(sig[:half])
+	s := new(big.Int).SetBytes(sig[half:])
+	return ecdsa.Verify(pub, t.digest(message), r, s), nil
+}
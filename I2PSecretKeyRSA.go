@@ -0,0 +1,173 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// rsaPublicExponent is the public exponent of all I2P RSA keys (F4).
+const rsaPublicExponent = 65537
+
+// RSASecretKey provides a type-safe wrapper for RSA keys of the
+// RSA_SHA256_2048, RSA_SHA384_3072 and RSA_SHA512_4096 signature types.
+// Signatures use PKCS#1 v1.5 padding.
+type RSASecretKey struct {
+	sigType SigType
+	key     *rsa.PrivateKey
+}
+
+func NewRSASecretKey(key *rsa.PrivateKey) (*RSASecretKey, error) {
+	if key == nil || key.N == nil {
+		return nil, fmt.Errorf("%w: nil RSA key", ErrInvalidKeyType)
+	}
+	if key.E != rsaPublicExponent {
+		return nil, fmt.Errorf("%w: RSA public exponent must be %d", ErrInvalidKeyType, rsaPublicExponent)
+	}
+	sigType, err := rsaSigType(key.Size())
+	if err != nil {
+		return nil, err
+	}
+	return &RSASecretKey{sigType: sigType, key: key}, nil
+}
+
+// rsaSigType returns the signature type for a modulus of the given size.
+func rsaSigType(size int) (SigType, error) {
+	for _, t := range []SigType{SigTypeRSA2048, SigTypeRSA3072, SigTypeRSA4096} {
+		if t.PublicKeyLen() == size {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unsupported RSA modulus of %d bytes", ErrInvalidKeyType, size)
+}
+
+// newRSAPrivateKey decodes a raw private key, stored as the modulus followed
+// by the private exponent. The modulus must match pub, the public key. The
+// primes are recovered so that signing can use the usual CRT optimizations.
+func newRSAPrivateKey(t SigType, raw, pub []byte) (*rsa.PrivateKey, error) {
+	if len(raw) != t.PrivateKeyLen() {
+		return nil, fmt.Errorf("%w: %s private key is %d bytes, want %d", ErrInvalidKeyType, t, len(raw), t.PrivateKeyLen())
+	}
+	half := len(raw) / 2
+	if !bytes.Equal(raw[:half], pub) {
+		return nil, fmt.Errorf("%w: %s private key does not match the public key", ErrInvalidKeyType, t)
+	}
+	n := new(big.Int).SetBytes(raw[:half])
+	d := new(big.Int).SetBytes(raw[half:])
+
+	p, q, err := recoverRSAPrimes(n, big.NewInt(rsaPublicExponent), d)
+	if err != nil {
+		return nil, err
+	}
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: n, E: rsaPublicExponent},
+		D:         d,
+		Primes:    []*big.Int{p, q},
+	}
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyType, err)
+	}
+	key.Precompute()
+	return key, nil
+}
+
+// recoverRSAPrimes factors n given both exponents, using the method from
+// NIST SP 800-56B appendix C.
+func recoverRSAPrimes(n, e, d *big.Int) (p, q *big.Int, err error) {
+	// A modulus of zero would make Exp compute unreduced powers
+	if n.Cmp(big.NewInt(1)) <= 0 || n.Bit(0) == 0 {
+		return nil, nil, fmt.Errorf("%w: invalid RSA modulus", ErrInvalidKeyType)
+	}
+	if d.Sign() <= 0 || d.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("%w: invalid RSA private exponent", ErrInvalidKeyType)
+	}
+	one := big.NewInt(1)
+	nMinusOne := new(big.Int).Sub(n, one)
+
+	// d*e - 1 = 2^s * t with t odd
+	t := new(big.Int).Mul(d, e)
+	t.Sub(t, one)
+	if t.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("%w: invalid RSA exponents", ErrInvalidKeyType)
+	}
+	s := 0
+	for t.Bit(0) == 0 {
+		t.Rsh(t, 1)
+		s++
+	}
+
+	for g := int64(2); g < 100; g++ {
+		x := new(big.Int).Exp(big.NewInt(g), t, n)
+		for i := 0; i < s; i++ {
+			y := new(big.Int).Exp(x, big.NewInt(2), n)
+			if y.Cmp(one) == 0 && x.Cmp(one) != 0 && x.Cmp(nMinusOne) != 0 {
+				p = new(big.Int).GCD(nil, nil, new(big.Int).Sub(x, one), n)
+				q = new(big.Int).Div(n, p)
+				return p, q, nil
+			}
+			x = y
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: could not factor RSA modulus", ErrInvalidKeyType)
+}
+
+// parseRSAPublicKey decodes a raw public key, which is the modulus.
+func parseRSAPublicKey(t SigType, raw []byte) (*rsa.PublicKey, error) {
+	if len(raw) != t.PublicKeyLen() || raw[0] == 0 {
+		return nil, fmt.Errorf("%w: invalid %s public key", ErrInvalidKeyType, t)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(raw), E: rsaPublicExponent}, nil
+}
+
+// generateRSAKeys returns a new key pair as the raw modulus and the modulus
+// followed by the private exponent.
+func generateRSAKeys(rand io.Reader, t SigType) (pub, priv []byte, err error) {
+	key, err := rsa.GenerateKey(rand, t.PublicKeyLen()*8)
+	if err != nil {
+		return nil, nil, err
+	}
+	size := t.PublicKeyLen()
+	pub = key.N.FillBytes(make([]byte, size))
+	return pub, append(pub, key.D.FillBytes(make([]byte, size))...), nil
+}
+
+func (k *RSASecretKey) Type() KeyType {
+	return KeyTypeRSA
+}
+
+// SigType returns the I2P signature type of the key.
+func (k *RSASecretKey) SigType() SigType {
+	return k.sigType
+}
+
+func (k *RSASecretKey) Raw() []byte {
+	size := k.sigType.PublicKeyLen()
+	return append(k.key.N.FillBytes(make([]byte, size)), k.key.D.FillBytes(make([]byte, size))...)
+}
+
+func (k *RSASecretKey) Public() crypto.PublicKey {
+	return &k.key.PublicKey
+}
+
+func (k *RSASecretKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if k == nil || k.key == nil {
+		return nil, fmt.Errorf("%w: invalid key state", ErrInvalidKeyType)
+	}
+	hashed, err := signingDigest(k.sigType, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.SignPKCS1v15(rand, k.key, k.sigType.Hash(), hashed)
+}
+
+// verifyRSA checks a PKCS#1 v1.5 signature over message.
+func verifyRSA(t SigType, key, message, sig []byte) (bool, error) {
+	pub, err := parseRSAPublicKey(t, key)
+	if err != nil {
+		return false, err
+	}
+	return rsa.VerifyPKCS1v15(pub, t.Hash(), t.digest(message), sig) == nil, nil
+}
@@ -0,0 +1,192 @@
+package i2pkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"filippo.io/edwards25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// redDSANonceSize is the amount of randomness mixed into each nonce.
+	redDSANonceSize = 80
+
+	// alphaPersonalization and alphaInfo are the constants of GENERATE_ALPHA
+	// from the encrypted LeaseSet specification.
+	alphaPersonalization = "I2PGenerateAlpha"
+	alphaInfo            = "i2pblinding1"
+)
+
+// RedDSASecretKey provides a type-safe wrapper for RedDSA_SHA512_Ed25519
+// keys. The private key is a scalar rather than an Ed25519 seed, which allows
+// it to be blinded, and signatures use a randomized nonce. Signatures verify
+// exactly like Ed25519 signatures.
+type RedDSASecretKey struct {
+	scalar *edwards25519.Scalar
+	public []byte
+}
+
+// NewRedDSASecretKey wraps a raw 32-byte little-endian private scalar.
+func NewRedDSASecretKey(raw []byte) (*RedDSASecretKey, error) {
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid RedDSA private key: %v", ErrInvalidKeyType, err)
+	}
+	return newRedDSASecretKey(s), nil
+}
+
+// NewRedDSASecretKeyFromEd25519 returns the RedDSA key with the same public
+// key as an Ed25519 key. This is how EdDSA_SHA512_Ed25519 destinations are
+// blinded.
+func NewRedDSASecretKeyFromEd25519(key ed25519.PrivateKey) (*RedDSASecretKey, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%w: invalid Ed25519 key size", ErrInvalidKeyType)
+	}
+	h := sha512.Sum512(key.Seed())
+	s, err := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyType, err)
+	}
+	return newRedDSASecretKey(s), nil
+}
+
+func newRedDSASecretKey(s *edwards25519.Scalar) *RedDSASecretKey {
+	return &RedDSASecretKey{
+		scalar: s,
+		public: new(edwards25519.Point).ScalarBaseMult(s).Bytes(),
+	}
+}
+
+// generateRedDSAKeys returns a new key pair as the raw point and scalar.
+func generateRedDSAKeys(rand io.Reader) (pub, priv []byte, err error) {
+	seed := make([]byte, 64)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, nil, err
+	}
+	s, err := edwards25519.NewScalar().SetUniformBytes(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	k := newRedDSASecretKey(s)
+	return k.public, k.Raw(), nil
+}
+
+func (k *RedDSASecretKey) Type() KeyType {
+	return KeyTypeRedDSA
+}
+
+// SigType returns the I2P signature type of the key.
+func (k *RedDSASecretKey) SigType() SigType {
+	return SigTypeRedDSA25519
+}
+
+func (k *RedDSASecretKey) Raw() []byte {
+	return k.scalar.Bytes()
+}
+
+// Public returns the public key. It has the same encoding as an Ed25519
+// public key and can be used with ed25519.Verify.
+func (k *RedDSASecretKey) Public() crypto.PublicKey {
+	return ed25519.PublicKey(append([]byte(nil), k.public...))
+}
+
+// Sign signs the full message, which must not be pre-hashed. Each signature
+// mixes 80 bytes from rand into the nonce.
+func (k *RedDSASecretKey) Sign(random io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if k == nil || k.scalar == nil {
+		return nil, fmt.Errorf("%w: invalid key state", ErrInvalidKeyType)
+	}
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, fmt.Errorf("%w: RedDSA signs the full message", ErrSigningFailed)
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+	t := make([]byte, redDSANonceSize)
+	if _, err := io.ReadFull(random, t); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSigningFailed, err)
+	}
+
+	r, err := hashToScalar(t, k.public, message)
+	if err != nil {
+		return nil, err
+	}
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+	c, err := hashToScalar(R, k.public, message)
+	if err != nil {
+		return nil, err
+	}
+	S := edwards25519.NewScalar().MultiplyAdd(c, k.scalar, r)
+	return append(R, S.Bytes()...), nil
+}
+
+// Blind returns the key blinded by alpha, a 32-byte scalar as returned by
+// GenerateAlpha. Its public key equals BlindPublicKey of the original.
+func (k *RedDSASecretKey) Blind(alpha []byte) (*RedDSASecretKey, error) {
+	a, err := edwards25519.NewScalar().SetCanonicalBytes(alpha)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid alpha: %v", ErrInvalidKeyType, err)
+	}
+	return newRedDSASecretKey(edwards25519.NewScalar().Add(k.scalar, a)), nil
+}
+
+// BlindPublicKey blinds an Ed25519 or RedDSA public key by alpha.
+func BlindPublicKey(pub, alpha []byte) ([]byte, error) {
+	A, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid public key: %v", ErrInvalidKeyType, err)
+	}
+	a, err := edwards25519.NewScalar().SetCanonicalBytes(alpha)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid alpha: %v", ErrInvalidKeyType, err)
+	}
+	blinded := new(edwards25519.Point).ScalarBaseMult(a)
+	return blinded.Add(blinded, A).Bytes(), nil
+}
+
+// GenerateAlpha derives the blinding factor for a signing public key on the
+// given day, following GENERATE_ALPHA from the encrypted LeaseSet
+// specification. sigType is the type of pub; the blinded key is always
+// RedDSA_SHA512_Ed25519. secret is optional.
+func GenerateAlpha(pub []byte, sigType SigType, date time.Time, secret string) ([]byte, error) {
+	if sigType != SigTypeEd25519 && sigType != SigTypeRedDSA25519 {
+		return nil, fmt.Errorf("%w: cannot blind %s keys", ErrInvalidKeyType, sigType)
+	}
+	if len(pub) != sigType.PublicKeyLen() {
+		return nil, fmt.Errorf("%w: %s public key is %d bytes, want %d", ErrInvalidKeyType, sigType, len(pub), sigType.PublicKeyLen())
+	}
+
+	keydata := make([]byte, len(pub)+4)
+	copy(keydata, pub)
+	binary.BigEndian.PutUint16(keydata[len(pub):], uint16(sigType))
+	binary.BigEndian.PutUint16(keydata[len(pub)+2:], uint16(SigTypeRedDSA25519))
+	salt := sha256.Sum256(append([]byte(alphaPersonalization), keydata...))
+
+	ikm := append([]byte(date.UTC().Format("20060102")), secret...)
+	seed := make([]byte, 64)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, ikm, salt[:], []byte(alphaInfo)), seed); err != nil {
+		return nil, err
+	}
+	alpha, err := edwards25519.NewScalar().SetUniformBytes(seed)
+	if err != nil {
+		return nil, err
+	}
+	return alpha.Bytes(), nil
+}
+
+// hashToScalar computes H*(data...), SHA-512 reduced modulo the group order.
+func hashToScalar(data ...[]byte) (*edwards25519.Scalar, error) {
+	h := sha512.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+}
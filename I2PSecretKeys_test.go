@@ -1,9 +1,14 @@
 package i2pkeys
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSecretKeyOperations(t *testing.T) {
@@ -59,3 +64,180 @@ func TestSecretKeyOperations(t *testing.T) {
 	      }
 	  })*/
 }
+
+func TestPrivateKeyFileSigning(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("Failed to generate test keys: %v", err)
+	}
+	dest, err := keys.Address.Destination()
+	if err != nil {
+		t.Fatalf("Destination() error = %v", err)
+	}
+	pub := ed25519.PublicKey(dest.SigningPublicKey())
+
+	t.Run("PrivateKeyFile", func(t *testing.T) {
+		p, err := keys.PrivateKeyFile()
+		if err != nil {
+			t.Fatalf("PrivateKeyFile() error = %v", err)
+		}
+		if len(p.EncryptionPrivateKey) != EncTypeX25519.PrivateKeyLen() {
+			t.Errorf("Wrong encryption key length, got %d, want %d", len(p.EncryptionPrivateKey), EncTypeX25519.PrivateKeyLen())
+		}
+		if len(p.SigningPrivateKey) != SigTypeEd25519.PrivateKeyLen() {
+			t.Errorf("Wrong signing key length, got %d, want %d", len(p.SigningPrivateKey), SigTypeEd25519.PrivateKeyLen())
+		}
+		if p.Offline != nil {
+			t.Error("Unexpected offline signature section")
+		}
+		if !bytes.Equal(p.Bytes(), keys.Private()) {
+			t.Error("Bytes() did not reproduce the private key")
+		}
+	})
+
+	t.Run("SecretKey", func(t *testing.T) {
+		sk, err := keys.SecretKey()
+		if err != nil {
+			t.Fatalf("SecretKey() error = %v", err)
+		}
+		if sk.Type() != KeyTypeEd25519 {
+			t.Errorf("Wrong key type, got %v, want %v", sk.Type(), KeyTypeEd25519)
+		}
+	})
+
+	t.Run("Sign", func(t *testing.T) {
+		message := []byte("test message")
+		sig, err := keys.Sign(rand.Reader, message, crypto.Hash(0))
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if !ed25519.Verify(pub, message, sig) {
+			t.Error("Signature verification failed")
+		}
+	})
+
+	t.Run("Offline signature", func(t *testing.T) {
+		p, err := keys.PrivateKeyFile()
+		if err != nil {
+			t.Fatalf("PrivateKeyFile() error = %v", err)
+		}
+		transientPub, transientPriv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate transient key: %v", err)
+		}
+		p.Offline = &OfflineSignature{
+			Expires:             time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+			TransientSigType:    SigTypeEd25519,
+			TransientPublicKey:  transientPub,
+			TransientPrivateKey: transientPriv.Seed(),
+		}
+		p.Offline.Signature = ed25519.Sign(ed25519.NewKeyFromSeed(p.SigningPrivateKey), p.Offline.signedBytes())
+		p.SigningPrivateKey = make([]byte, SigTypeEd25519.PrivateKeyLen())
+
+		offlineKeys, err := p.I2PKeys()
+		if err != nil {
+			t.Fatalf("I2PKeys() error = %v", err)
+		}
+		parsed, err := offlineKeys.PrivateKeyFile()
+		if err != nil {
+			t.Fatalf("PrivateKeyFile() error = %v", err)
+		}
+		if parsed.Offline == nil {
+			t.Fatal("Offline signature section was not parsed")
+		}
+		if !parsed.Offline.Expires.Equal(p.Offline.Expires) {
+			t.Errorf("Wrong expiration, got %v, want %v", parsed.Offline.Expires, p.Offline.Expires)
+		}
+
+		message := []byte("test message")
+		sig, err := offlineKeys.Sign(rand.Reader, message, crypto.Hash(0))
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if !ed25519.Verify(transientPub, message, sig) {
+			t.Error("Signature was not made with the transient key")
+		}
+	})
+
+	t.Run("Mismatched address", func(t *testing.T) {
+		other, err := NewLocalDestination()
+		if err != nil {
+			t.Fatalf("Failed to generate test keys: %v", err)
+		}
+		mixed := I2PKeys{
+			Address: other.Address,
+			Both:    other.Address.Base64() + strings.TrimPrefix(keys.Both, keys.Address.Base64()),
+		}
+		if _, err := mixed.SecretKey(); !errors.Is(err, ErrInvalidPrivateKey) {
+			t.Errorf("Expected ErrInvalidPrivateKey, got %v", err)
+		}
+	})
+}
+
+func TestVerify(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("Failed to generate test keys: %v", err)
+	}
+	message := []byte("test message")
+	sig, err := keys.Sign(rand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	t.Run("Address", func(t *testing.T) {
+		if err := keys.Address.Verify(message, sig); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+		if err := keys.Address.Verify([]byte("other message"), sig); !errors.Is(err, ErrVerificationFailed) {
+			t.Errorf("Expected ErrVerificationFailed, got %v", err)
+		}
+		if err := keys.Address.Verify(message, sig[:len(sig)-1]); !errors.Is(err, ErrVerificationFailed) {
+			t.Errorf("Expected ErrVerificationFailed for short signature, got %v", err)
+		}
+	})
+
+	t.Run("Public", func(t *testing.T) {
+		pub, ok := keys.Public().(*SigningPublicKey)
+		if !ok {
+			t.Fatalf("Public() returned %T, want *SigningPublicKey", keys.Public())
+		}
+		if pub.SigType() != SigTypeEd25519 {
+			t.Errorf("Wrong signature type, got %s, want %s", pub.SigType(), SigTypeEd25519)
+		}
+		if err := pub.Verify(message, sig); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+		sk, err := keys.SecretKey()
+		if err != nil {
+			t.Fatalf("SecretKey() error = %v", err)
+		}
+		if !bytes.Equal(pub.Raw(), sk.Public().(ed25519.PublicKey)) {
+			t.Error("Public() does not match the secret key")
+		}
+	})
+
+	t.Run("Offline signature", func(t *testing.T) {
+		p, err := keys.PrivateKeyFile()
+		if err != nil {
+			t.Fatalf("PrivateKeyFile() error = %v", err)
+		}
+		transientPub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate transient key: %v", err)
+		}
+		offline := &OfflineSignature{
+			Expires:            time.Now().Add(time.Hour),
+			TransientSigType:   SigTypeEd25519,
+			TransientPublicKey: transientPub,
+		}
+		offline.Signature = ed25519.Sign(ed25519.NewKeyFromSeed(p.SigningPrivateKey), offline.signedBytes())
+		if err := offline.Verify(p.Destination); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+		offline.TransientSigType = SigTypeRedDSA25519
+		if err := offline.Verify(p.Destination); !errors.Is(err, ErrVerificationFailed) {
+			t.Errorf("Expected ErrVerificationFailed, got %v", err)
+		}
+	})
+}
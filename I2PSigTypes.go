@@ -0,0 +1,181 @@
+package i2pkeys
+
+import (
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SigType identifies a signing algorithm by its I2P type code, as carried in
+// KEY certificates and accepted by SAM as SIGNATURE_TYPE.
+type SigType uint16
+
+const (
+	SigTypeDSASHA1     SigType = 0
+	SigTypeECDSAP256   SigType = 1
+	SigTypeECDSAP384   SigType = 2
+	SigTypeECDSAP521   SigType = 3
+	SigTypeRSA2048     SigType = 4
+	SigTypeRSA3072     SigType = 5
+	SigTypeRSA4096     SigType = 6
+	SigTypeEd25519     SigType = 7
+	SigTypeEd25519ph   SigType = 8
+	SigTypeRedDSA25519 SigType = 11
+)
+
+// EncType identifies a public key encryption algorithm by its I2P type code.
+type EncType uint16
+
+const (
+	EncTypeElGamal EncType = 0
+	EncTypeECP256  EncType = 1
+	EncTypeECP384  EncType = 2
+	EncTypeECP521  EncType = 3
+	EncTypeX25519  EncType = 4
+)
+
+type sigTypeInfo struct {
+	name          string
+	publicKeyLen  int
+	privateKeyLen int
+	signatureLen  int
+	hash          crypto.Hash
+}
+
+type encTypeInfo struct {
+	name          string
+	publicKeyLen  int
+	privateKeyLen int
+}
+
+// Key and signature lengths and hash functions from the I2P common structures
+// specification.
+var (
+	sigTypes = map[SigType]sigTypeInfo{
+		SigTypeDSASHA1:     {"DSA_SHA1", 128, 20, 40, crypto.SHA1},
+		SigTypeECDSAP256:   {"ECDSA_SHA256_P256", 64, 32, 64, crypto.SHA256},
+		SigTypeECDSAP384:   {"ECDSA_SHA384_P384", 96, 48, 96, crypto.SHA384},
+		SigTypeECDSAP521:   {"ECDSA_SHA512_P521", 132, 66, 132, crypto.SHA512},
+		SigTypeRSA2048:     {"RSA_SHA256_2048", 256, 512, 256, crypto.SHA256},
+		SigTypeRSA3072:     {"RSA_SHA384_3072", 384, 768, 384, crypto.SHA384},
+		SigTypeRSA4096:     {"RSA_SHA512_4096", 512, 1024, 512, crypto.SHA512},
+		SigTypeEd25519:     {"EdDSA_SHA512_Ed25519", 32, 32, 64, 0},
+		SigTypeEd25519ph:   {"EdDSA_SHA512_Ed25519ph", 32, 32, 64, crypto.SHA512},
+		SigTypeRedDSA25519: {"RedDSA_SHA512_Ed25519", 32, 32, 64, 0},
+	}
+	encTypes = map[EncType]encTypeInfo{
+		EncTypeElGamal: {"ELGAMAL_2048", 256, 256},
+		EncTypeECP256:  {"EC_P256", 64, 32},
+		EncTypeECP384:  {"EC_P384", 96, 48},
+		EncTypeECP521:  {"EC_P521", 132, 66},
+		EncTypeX25519:  {"ECIES_X25519", 32, 32},
+	}
+)
+
+// ParseSigType parses a signature type from its numeric code or its name,
+// e.g. "7" or "EdDSA_SHA512_Ed25519".
+func ParseSigType(s string) (SigType, error) {
+	s = strings.TrimSpace(s)
+	if code, err := strconv.ParseUint(s, 10, 16); err == nil {
+		t := SigType(code)
+		if !t.IsKnown() {
+			return 0, fmt.Errorf("%w: unknown signature type %d", ErrInvalidKeyType, code)
+		}
+		return t, nil
+	}
+	for t, info := range sigTypes {
+		if strings.EqualFold(info.name, s) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown signature type %q", ErrInvalidKeyType, s)
+}
+
+// IsKnown reports whether the signature type is defined by the specification.
+func (t SigType) IsKnown() bool {
+	_, ok := sigTypes[t]
+	return ok
+}
+
+// String returns the specification name of the signature type.
+func (t SigType) String() string {
+	if info, ok := sigTypes[t]; ok {
+		return info.name
+	}
+	return "SigType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// PublicKeyLen returns the length of a signing public key of this type.
+func (t SigType) PublicKeyLen() int {
+	return sigTypes[t].publicKeyLen
+}
+
+// PrivateKeyLen returns the length of a signing private key of this type.
+func (t SigType) PrivateKeyLen() int {
+	return sigTypes[t].privateKeyLen
+}
+
+// SignatureLen returns the length of a signature of this type.
+func (t SigType) SignatureLen() int {
+	return sigTypes[t].signatureLen
+}
+
+// Hash returns the hash function messages are digested with before signing,
+// or zero for types such as Ed25519 that sign the full message.
+func (t SigType) Hash() crypto.Hash {
+	return sigTypes[t].hash
+}
+
+// digest returns the message digested with the type's hash function.
+func (t SigType) digest(message []byte) []byte {
+	h := t.Hash().New()
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// ParseEncType parses an encryption type from its numeric code or its name,
+// e.g. "4" or "ECIES_X25519".
+func ParseEncType(s string) (EncType, error) {
+	s = strings.TrimSpace(s)
+	if code, err := strconv.ParseUint(s, 10, 16); err == nil {
+		t := EncType(code)
+		if !t.IsKnown() {
+			return 0, fmt.Errorf("%w: unknown encryption type %d", ErrInvalidKeyType, code)
+		}
+		return t, nil
+	}
+	for t, info := range encTypes {
+		if strings.EqualFold(info.name, s) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown encryption type %q", ErrInvalidKeyType, s)
+}
+
+// IsKnown reports whether the encryption type is defined by the specification.
+func (t EncType) IsKnown() bool {
+	_, ok := encTypes[t]
+	return ok
+}
+
+// String returns the specification name of the encryption type.
+func (t EncType) String() string {
+	if info, ok := encTypes[t]; ok {
+		return info.name
+	}
+	return "EncType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// PublicKeyLen returns the length of an encryption public key of this type.
+func (t EncType) PublicKeyLen() int {
+	return encTypes[t].publicKeyLen
+}
+
+// PrivateKeyLen returns the length of an encryption private key of this type.
+func (t EncType) PrivateKeyLen() int {
+	return encTypes[t].privateKeyLen
+}
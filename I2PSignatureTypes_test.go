@@ -0,0 +1,233 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// signatureTypeCases lists the signature types supported by local generation.
+var signatureTypeCases = []SigType{
+	SigTypeEd25519,
+	SigTypeECDSAP256,
+	SigTypeECDSAP384,
+	SigTypeECDSAP521,
+	SigTypeDSASHA1,
+	SigTypeRSA2048,
+	SigTypeRSA3072,
+	SigTypeRSA4096,
+	SigTypeRedDSA25519,
+}
+
+func Test_SignatureTypes(t *testing.T) {
+	message := []byte("test message")
+
+	for _, sigType := range signatureTypeCases {
+		t.Run(sigType.String(), func(t *testing.T) {
+			keys, err := NewLocalDestination(WithSigType(sigType))
+			if err != nil {
+				t.Fatalf("NewLocalDestination failed: '%v'", err)
+			}
+			got, err := keys.Address.SigType()
+			if err != nil {
+				t.Fatalf("SigType failed: '%v'", err)
+			}
+			if got != sigType {
+				t.Fatalf("Wrong signature type. Got %s, want %s", got, sigType)
+			}
+
+			sig, err := keys.Sign(rand.Reader, message, crypto.Hash(0))
+			if err != nil {
+				t.Fatalf("Sign failed: '%v'", err)
+			}
+			if len(sig) != sigType.SignatureLen() {
+				t.Errorf("Wrong signature length. Got %d, want %d", len(sig), sigType.SignatureLen())
+			}
+			if err := keys.Address.Verify(message, sig); err != nil {
+				t.Errorf("Verify failed: '%v'", err)
+			}
+			if err := keys.Address.Verify([]byte("other message"), sig); !errors.Is(err, ErrVerificationFailed) {
+				t.Errorf("Expected ErrVerificationFailed, got '%v'", err)
+			}
+
+			if sigType.Hash() != 0 {
+				sig, err := keys.Sign(rand.Reader, sigType.digest(message), sigType.Hash())
+				if err != nil {
+					t.Fatalf("Sign with digest failed: '%v'", err)
+				}
+				if err := keys.Address.Verify(message, sig); err != nil {
+					t.Errorf("Verify of digest signature failed: '%v'", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_LegacyDSADestination(t *testing.T) {
+	keys, err := NewLocalDestination(WithSigType(SigTypeDSASHA1), WithEncType(EncTypeElGamal))
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: '%v'", err)
+	}
+	addr := keys.Address
+	dest, err := addr.Destination()
+	if err != nil {
+		t.Fatalf("Destination failed: '%v'", err)
+	}
+	if dest.Certificate().Type != CertTypeNull || len(dest.Bytes()) != MinDestinationSize {
+		t.Fatalf("Legacy destination should have an empty NULL certificate")
+	}
+
+	message := []byte("legacy.i2p=" + addr.Base64())
+	sig, err := keys.Sign(rand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("Sign failed: '%v'", err)
+	}
+	if len(sig) != 40 {
+		t.Errorf("Wrong signature length. Got %d, want 40", len(sig))
+	}
+	if err := addr.Verify(message, sig); err != nil {
+		t.Errorf("Verify failed: '%v'", err)
+	}
+}
+
+func Test_RSAKeyCertificate(t *testing.T) {
+	keys, err := NewLocalDestination(WithSigType(SigTypeRSA4096))
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: '%v'", err)
+	}
+	addr, err := NewI2PAddrFromString(keys.Address.Base64())
+	if err != nil {
+		t.Fatalf("NewI2PAddrFromString failed: '%v'", err)
+	}
+	dest, err := addr.Destination()
+	if err != nil {
+		t.Fatalf("Destination failed: '%v'", err)
+	}
+	kc, err := dest.KeyCertificate()
+	if err != nil {
+		t.Fatalf("KeyCertificate failed: '%v'", err)
+	}
+	if want := SigTypeRSA4096.PublicKeyLen() - SigningKeyAreaSize; len(kc.ExcessSigningKey) != want {
+		t.Errorf("Wrong excess key length. Got %d, want %d", len(kc.ExcessSigningKey), want)
+	}
+	if len(dest.SigningPublicKey()) != SigTypeRSA4096.PublicKeyLen() {
+		t.Errorf("Wrong signing public key length. Got %d, want %d", len(dest.SigningPublicKey()), SigTypeRSA4096.PublicKeyLen())
+	}
+
+	sk, err := keys.SecretKey()
+	if err != nil {
+		t.Fatalf("SecretKey failed: '%v'", err)
+	}
+	if sk.Type() != KeyTypeRSA {
+		t.Errorf("Wrong key type. Got %v, want %v", sk.Type(), KeyTypeRSA)
+	}
+	p, err := keys.PrivateKeyFile()
+	if err != nil {
+		t.Fatalf("PrivateKeyFile failed: '%v'", err)
+	}
+	if !bytes.Equal(sk.Raw(), p.SigningPrivateKey) {
+		t.Error("Raw did not reproduce the private key")
+	}
+
+	t.Run("Crafted modulus", func(t *testing.T) {
+		half := len(p.SigningPrivateKey) / 2
+		d := p.SigningPrivateKey[half:]
+		for name, modulus := range map[string][]byte{
+			"zero":     make([]byte, half),
+			"even":     append(make([]byte, half-1), 2),
+			"mismatch": append([]byte{1}, p.SigningPrivateKey[1:half]...),
+		} {
+			raw := append(append([]byte(nil), modulus...), d...)
+			if _, err := newRSAPrivateKey(SigTypeRSA4096, raw, dest.SigningPublicKey()); !errors.Is(err, ErrInvalidKeyType) {
+				t.Errorf("Expected ErrInvalidKeyType for %s modulus, got '%v'", name, err)
+			}
+		}
+		// Factor recovery must not run unreduced exponentiations either
+		e := big.NewInt(rsaPublicExponent)
+		for _, n := range []int64{0, 1, 2} {
+			if _, _, err := recoverRSAPrimes(big.NewInt(n), e, new(big.Int).SetBytes(d)); !errors.Is(err, ErrInvalidKeyType) {
+				t.Errorf("Expected recoverRSAPrimes to reject modulus %d, got '%v'", n, err)
+			}
+		}
+
+		crafted := *p
+		crafted.SigningPrivateKey = append(make([]byte, half), p.SigningPrivateKey[half:]...)
+		craftedKeys, err := crafted.I2PKeys()
+		if err != nil {
+			t.Fatalf("I2PKeys failed: '%v'", err)
+		}
+		if _, err := craftedKeys.SecretKey(); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType, got '%v'", err)
+		}
+	})
+}
+
+func Test_RedDSABlinding(t *testing.T) {
+	message := []byte("test message")
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: '%v'", err)
+	}
+	dest, err := keys.Address.Destination()
+	if err != nil {
+		t.Fatalf("Destination failed: '%v'", err)
+	}
+	edKey, err := keys.Ed25519PrivateKey()
+	if err != nil {
+		t.Fatalf("Ed25519PrivateKey failed: '%v'", err)
+	}
+	sk, err := NewRedDSASecretKeyFromEd25519(edKey)
+	if err != nil {
+		t.Fatalf("NewRedDSASecretKeyFromEd25519 failed: '%v'", err)
+	}
+	if !bytes.Equal(sk.Public().(ed25519.PublicKey), dest.SigningPublicKey()) {
+		t.Fatal("Converted key does not match the destination")
+	}
+
+	alpha, err := GenerateAlpha(dest.SigningPublicKey(), SigTypeEd25519, date, "")
+	if err != nil {
+		t.Fatalf("GenerateAlpha failed: '%v'", err)
+	}
+	again, err := GenerateAlpha(dest.SigningPublicKey(), SigTypeEd25519, date.Add(time.Hour), "")
+	if err != nil || !bytes.Equal(alpha, again) {
+		t.Error("GenerateAlpha should be stable within a day")
+	}
+	nextDay, err := GenerateAlpha(dest.SigningPublicKey(), SigTypeEd25519, date.AddDate(0, 0, 1), "")
+	if err != nil || bytes.Equal(alpha, nextDay) {
+		t.Error("GenerateAlpha should change from day to day")
+	}
+
+	blinded, err := sk.Blind(alpha)
+	if err != nil {
+		t.Fatalf("Blind failed: '%v'", err)
+	}
+	blindedPub, err := BlindPublicKey(dest.SigningPublicKey(), alpha)
+	if err != nil {
+		t.Fatalf("BlindPublicKey failed: '%v'", err)
+	}
+	if !bytes.Equal(blinded.Public().(ed25519.PublicKey), blindedPub) {
+		t.Fatal("Blinded private and public keys do not match")
+	}
+
+	sig, err := blinded.Sign(rand.Reader, message, crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("Sign failed: '%v'", err)
+	}
+	verifier, err := NewSigningPublicKey(SigTypeRedDSA25519, blindedPub)
+	if err != nil {
+		t.Fatalf("NewSigningPublicKey failed: '%v'", err)
+	}
+	if err := verifier.Verify(message, sig); err != nil {
+		t.Errorf("Verify failed: '%v'", err)
+	}
+	if err := dest.Verify(message, sig); err == nil {
+		t.Error("Blinded signature should not verify with the unblinded key")
+	}
+}
@@ -0,0 +1,258 @@
+package i2pkeys
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyFileVersion is the current version of the self-describing key file
+// format written by WriteKeyFile.
+const KeyFileVersion = 1
+
+// keyFileMagic opens every versioned key file. It cannot start a legacy file,
+// which begins with a base64 address.
+const keyFileMagic = "# i2pkeys key file\n"
+
+// Versioned key files are line-oriented "name: value" records after the magic
+// line. The final checksum record holds the SHA-256 of everything before it,
+// so truncated or edited files are detected.
+const (
+	keyFieldVersion  = "version"
+	keyFieldSigType  = "sigtype"
+	keyFieldEncType  = "enctype"
+	keyFieldCreated  = "created"
+	keyFieldLabel    = "label"
+	keyFieldAddress  = "address"
+	keyFieldKeys     = "keys"
+	keyFieldChecksum = "checksum"
+)
+
+// KeyFileMetadata describes keys stored in a versioned key file.
+type KeyFileMetadata struct {
+	Version int
+	SigType SigType
+	EncType EncType
+	Created time.Time
+	Label   string
+}
+
+// WriteKeyFile writes keys in the versioned key file format. The signature
+// and encryption types are taken from the keys' destination.
+func WriteKeyFile(w io.Writer, k I2PKeys, label string) error {
+	return writeKeyFile(w, k, label, time.Now())
+}
+
+func writeKeyFile(w io.Writer, k I2PKeys, label string, created time.Time) error {
+	log.Debug("Storing versioned keys", "label", label)
+	dest, err := k.Address.Destination()
+	if err != nil {
+		log.Error("Error parsing address", "error", err)
+		return fmt.Errorf("error parsing address: %w", err)
+	}
+	if !strings.HasPrefix(k.Both, k.Address.Base64()) {
+		return fmt.Errorf("%w: keys do not start with address", ErrInvalidPrivateKey)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(keyFileMagic)
+	writeKeyField(&buf, keyFieldVersion, strconv.Itoa(KeyFileVersion))
+	writeKeyField(&buf, keyFieldSigType, dest.SigType().String())
+	writeKeyField(&buf, keyFieldEncType, dest.EncType().String())
+	writeKeyField(&buf, keyFieldCreated, created.UTC().Format(time.RFC3339))
+	writeKeyField(&buf, keyFieldLabel, strconv.Quote(label))
+	writeKeyField(&buf, keyFieldAddress, k.Address.Base64())
+	writeKeyField(&buf, keyFieldKeys, k.Both)
+	sum := sha256.Sum256(buf.Bytes())
+	writeKeyField(&buf, keyFieldChecksum, hex.EncodeToString(sum[:]))
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Error("Error writing keys", "error", err)
+		return fmt.Errorf("error writing keys: %w", err)
+	}
+	return nil
+}
+
+func writeKeyField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// ReadKeyFile reads keys written by WriteKeyFile, verifying the checksum and
+// that the recorded types match the destination.
+func ReadKeyFile(r io.Reader) (I2PKeys, *KeyFileMetadata, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return I2PKeys{}, nil, fmt.Errorf("error copying from reader: %w", err)
+	}
+	return parseKeyFile(data)
+}
+
+// isKeyFile reports whether data is in the versioned key file format.
+func isKeyFile(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(keyFileMagic))
+}
+
+func parseKeyFile(data []byte) (I2PKeys, *KeyFileMetadata, error) {
+	if !isKeyFile(data) {
+		return I2PKeys{}, nil, fmt.Errorf("%w: not a versioned key file", ErrCorruptKeyFile)
+	}
+
+	fields := make(map[string]string)
+	var checked int
+	scanner := bufio.NewScanner(bytes.NewReader(data[len(keyFileMagic):]))
+	scanner.Buffer(make([]byte, 0, 4096), 64*1024)
+	offset := len(keyFileMagic)
+	for lineNo := 2; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		name, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return I2PKeys{}, nil, fmt.Errorf("%w: malformed line %d", ErrCorruptKeyFile, lineNo)
+		}
+		if _, dup := fields[name]; dup {
+			return I2PKeys{}, nil, fmt.Errorf("%w: duplicate field %q", ErrCorruptKeyFile, name)
+		}
+		if name == keyFieldChecksum {
+			checked = offset
+		}
+		fields[name] = value
+		offset += len(line) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return I2PKeys{}, nil, fmt.Errorf("%w: %v", ErrCorruptKeyFile, err)
+	}
+
+	sum, ok := fields[keyFieldChecksum]
+	if !ok || checked+len(keyFieldChecksum)+2+len(sum)+1 != len(data) {
+		return I2PKeys{}, nil, fmt.Errorf("%w: missing or misplaced checksum", ErrCorruptKeyFile)
+	}
+	want := sha256.Sum256(data[:checked])
+	if sum != hex.EncodeToString(want[:]) {
+		return I2PKeys{}, nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptKeyFile)
+	}
+
+	meta, err := parseKeyFileMetadata(fields)
+	if err != nil {
+		return I2PKeys{}, nil, err
+	}
+	k := I2PKeys{Address: I2PAddr(fields[keyFieldAddress]), Both: fields[keyFieldKeys]}
+	if !strings.HasPrefix(k.Both, k.Address.Base64()) {
+		return I2PKeys{}, nil, fmt.Errorf("%w: keys do not start with address", ErrCorruptKeyFile)
+	}
+	dest, err := k.Address.Destination()
+	if err != nil {
+		return I2PKeys{}, nil, fmt.Errorf("%w: %v", ErrCorruptKeyFile, err)
+	}
+	if dest.SigType() != meta.SigType || dest.EncType() != meta.EncType {
+		return I2PKeys{}, nil, fmt.Errorf("%w: recorded key types do not match the address", ErrCorruptKeyFile)
+	}
+	return k, meta, nil
+}
+
+func parseKeyFileMetadata(fields map[string]string) (*KeyFileMetadata, error) {
+	for _, name := range []string{keyFieldVersion, keyFieldSigType, keyFieldEncType, keyFieldCreated, keyFieldLabel, keyFieldAddress, keyFieldKeys} {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("%w: missing field %q", ErrCorruptKeyFile, name)
+		}
+	}
+
+	meta := &KeyFileMetadata{}
+	var err error
+	if meta.Version, err = strconv.Atoi(fields[keyFieldVersion]); err != nil {
+		return nil, fmt.Errorf("%w: invalid version: %v", ErrCorruptKeyFile, err)
+	}
+	if meta.Version != KeyFileVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCorruptKeyFile, meta.Version)
+	}
+	if meta.SigType, err = ParseSigType(fields[keyFieldSigType]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptKeyFile, err)
+	}
+	if meta.EncType, err = ParseEncType(fields[keyFieldEncType]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptKeyFile, err)
+	}
+	if meta.Created, err = time.Parse(time.RFC3339, fields[keyFieldCreated]); err != nil {
+		return nil, fmt.Errorf("%w: invalid creation time: %v", ErrCorruptKeyFile, err)
+	}
+	if meta.Label, err = strconv.Unquote(fields[keyFieldLabel]); err != nil {
+		return nil, fmt.Errorf("%w: invalid label: %v", ErrCorruptKeyFile, err)
+	}
+	return meta, nil
+}
+
+// StoreKeyFile writes keys to a file in the versioned key file format,
+// following the same overwrite and backup rules as StoreKeysWithOptions.
+func StoreKeyFile(k I2PKeys, path, label string, options ...StoreOption) error {
+	log.Debug("Storing versioned keys to file", "filename", path)
+	var buf bytes.Buffer
+	if err := WriteKeyFile(&buf, k, label); err != nil {
+		return err
+	}
+	return storeKeyData(k, path, buf.Bytes(), loadKeyData, options)
+}
+
+// LoadKeyFile reads keys and metadata from a versioned key file.
+func LoadKeyFile(path string) (I2PKeys, *KeyFileMetadata, error) {
+	log.Debug("Loading versioned keys from file", "filename", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Error("Error opening file", "filename", path, "error", err)
+		return I2PKeys{}, nil, fmt.Errorf("error opening file: %w", err)
+	}
+	return parseKeyFile(data)
+}
+
+// MigrateKeyFile upgrades a legacy key file, as written by StoreKeys, to the
+// versioned format in place. The file's modification time is recorded as the
+// creation time. The new contents are checked before they atomically replace
+// the old file, so an interrupted migration leaves the legacy file intact, and
+// the result is only readable by its owner. The legacy file is kept as a
+// backup as described for StoreKeysWithOptions. Files already in the versioned
+// format are left unchanged; binary router files and encrypted files are
+// refused, since their readers cannot load the versioned format.
+func MigrateKeyFile(path, label string) error {
+	log.Debug("Migrating key file", "filename", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	if isKeyFile(data) {
+		log.Debug("Key file is already versioned", "filename", path)
+		return nil
+	}
+	if bytes.HasPrefix(data, []byte(encryptedKeysMagic)) {
+		return ErrKeysEncrypted
+	}
+	if _, err := ParsePrivateKeyFile(data); err == nil {
+		return fmt.Errorf("%s is a binary private key file, not a legacy key file", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	k, err := LoadKeysIncompat(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := writeKeyFile(&buf, k, label, info.ModTime()); err != nil {
+		return err
+	}
+	migrated, _, err := parseKeyFile(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("error checking migrated key file: %w", err)
+	}
+	if migrated != k {
+		return fmt.Errorf("migrated key file does not match the original")
+	}
+	return storeKeyData(k, path, buf.Bytes(), loadKeyData, []StoreOption{WithOverwrite(true)})
+}
@@ -0,0 +1,165 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_KeyFile(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteKeyFile(&buf, *keys, "my eepsite\nprimary"); err != nil {
+		t.Fatalf("WriteKeyFile failed: %v", err)
+	}
+	content := buf.Bytes()
+
+	t.Run("Read", func(t *testing.T) {
+		loaded, meta, err := ReadKeyFile(bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("ReadKeyFile failed: %v", err)
+		}
+		if loaded != *keys {
+			t.Error("Loaded keys do not match the original")
+		}
+		if meta.Version != KeyFileVersion || meta.SigType != SigTypeEd25519 || meta.EncType != EncTypeX25519 {
+			t.Errorf("Wrong metadata: %+v", meta)
+		}
+		if meta.Label != "my eepsite\nprimary" {
+			t.Errorf("Wrong label. Got %q", meta.Label)
+		}
+		if time.Since(meta.Created) > time.Minute {
+			t.Errorf("Wrong creation time: %v", meta.Created)
+		}
+	})
+
+	t.Run("Auto-detect", func(t *testing.T) {
+		loaded, err := LoadKeysIncompat(bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("LoadKeysIncompat failed: %v", err)
+		}
+		if loaded != *keys {
+			t.Error("Loaded keys do not match the original")
+		}
+	})
+
+	t.Run("Corruption", func(t *testing.T) {
+		corrupt := bytes.Replace(content, []byte("version: 1"), []byte("version: 2"), 1)
+		if _, _, err := ReadKeyFile(bytes.NewReader(corrupt)); !errors.Is(err, ErrCorruptKeyFile) {
+			t.Errorf("Expected ErrCorruptKeyFile for edited file, got %v", err)
+		}
+		for _, n := range []int{len(keyFileMagic) + 10, len(content) / 2, len(content) - 1} {
+			if _, err := LoadKeysIncompat(bytes.NewReader(content[:n])); !errors.Is(err, ErrCorruptKeyFile) {
+				t.Errorf("Expected ErrCorruptKeyFile for file truncated to %d bytes, got %v", n, err)
+			}
+		}
+	})
+
+	t.Run("Migrate", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.dat")
+		var legacy bytes.Buffer
+		if err := StoreKeysIncompat(*keys, &legacy); err != nil {
+			t.Fatalf("StoreKeysIncompat failed: %v", err)
+		}
+		if err := os.WriteFile(path, legacy.Bytes(), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.Chmod(path, 0o644); err != nil {
+			t.Fatalf("Chmod failed: %v", err)
+		}
+		created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := os.Chtimes(path, created, created); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+
+		if err := MigrateKeyFile(path, "migrated"); err != nil {
+			t.Fatalf("MigrateKeyFile failed: %v", err)
+		}
+		loaded, meta, err := LoadKeyFile(path)
+		if err != nil {
+			t.Fatalf("LoadKeyFile failed: %v", err)
+		}
+		if loaded != *keys {
+			t.Error("Migrated keys do not match the original")
+		}
+		if !meta.Created.Equal(created) || meta.Label != "migrated" {
+			t.Errorf("Wrong metadata: %+v", meta)
+		}
+		backup, err := os.ReadFile(path + ".1")
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !bytes.Equal(backup, legacy.Bytes()) {
+			t.Error("Legacy file was not kept as a backup")
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Wrong file permissions. Got %o, want 600", perm)
+		}
+
+		migrated, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if err := MigrateKeyFile(path, "again"); err != nil {
+			t.Fatalf("MigrateKeyFile on versioned file failed: %v", err)
+		}
+		again, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !bytes.Equal(migrated, again) {
+			t.Error("MigrateKeyFile changed an already versioned file")
+		}
+	})
+
+	t.Run("Migrate binary", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "eepPriv.dat")
+		var binary bytes.Buffer
+		if err := WritePrivateKeyFile(&binary, *keys); err != nil {
+			t.Fatalf("WritePrivateKeyFile failed: %v", err)
+		}
+		if err := os.WriteFile(path, binary.Bytes(), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := MigrateKeyFile(path, ""); err == nil {
+			t.Error("Expected MigrateKeyFile to refuse a binary private key file")
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !bytes.Equal(content, binary.Bytes()) {
+			t.Error("Refused migration modified the binary file")
+		}
+	})
+
+	t.Run("Migrate invalid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.dat")
+		garbage := []byte(strings.Repeat("A", 600) + "\nBBBB")
+		if err := os.WriteFile(path, garbage, 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := MigrateKeyFile(path, ""); err == nil {
+			t.Error("Expected MigrateKeyFile to reject invalid legacy keys")
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !bytes.Equal(content, garbage) {
+			t.Error("Failed migration modified the legacy file")
+		}
+	})
+}
@@ -2,6 +2,7 @@ package i2pkeys
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,60 +10,111 @@ import (
 	"strings"
 )
 
-// LoadKeysIncompat loads keys from a non-standard format
+// LoadKeysIncompat loads keys from a non-standard format. Files in the
+// versioned format written by WriteKeyFile and binary private key files
+// written by WritePrivateKeyFile are detected and loaded too.
 func LoadKeysIncompat(r io.Reader) (I2PKeys, error) {
 	log.Debug("Loading keys from reader")
 	var buff bytes.Buffer
 	_, err := io.Copy(&buff, r)
 	if err != nil {
-		log.WithError(err).Error("Error copying from reader, did not load keys")
+		log.Error("Error copying from reader, did not load keys", "error", err)
 		return I2PKeys{}, fmt.Errorf("error copying from reader: %w", err)
 	}
+	if bytes.HasPrefix(buff.Bytes(), []byte(encryptedKeysMagic)) {
+		log.Error("Error parsing keys", "error", ErrKeysEncrypted)
+		return I2PKeys{}, ErrKeysEncrypted
+	}
+	if isKeyFile(buff.Bytes()) {
+		k, _, err := parseKeyFile(buff.Bytes())
+		if err != nil {
+			log.Error("Error parsing versioned keys", "error", err)
+		}
+		return k, err
+	}
+
+	if p, err := ParsePrivateKeyFile(buff.Bytes()); err == nil {
+		// Text formats can never parse as a binary destination, whose
+		// certificate type byte is at most 5
+		log.Debug("Loading keys from binary private key file")
+		return p.I2PKeys()
+	}
 
 	parts := strings.Split(buff.String(), "\n")
 	if len(parts) < 2 {
 		err := errors.New("invalid key format: not enough data")
-		log.WithError(err).Error("Error parsing keys")
+		log.Error("Error parsing keys", "error", err)
 		return I2PKeys{}, err
 	}
 
 	k := I2PKeys{I2PAddr(parts[0]), parts[1]}
-	log.WithField("keys", k).Debug("Loaded keys")
+	log.Debug("Loaded keys", "address", k.Address.Base32())
 	return k, nil
 }
 
 // load keys from non-standard format by specifying a text file.
 // If the file does not exist, generate keys, otherwise, fail
-// closed.
+// closed. Generation holds an advisory lock on r + ".lock", so when
+// several processes start at once exactly one destination is created
+// and the others load it.
 func LoadKeys(r string) (I2PKeys, error) {
-	log.WithField("filename", r).Debug("Loading keys from file")
+	return loadOrGenerateKeys(r, func() (*I2PKeys, error) {
+		return NewDestination()
+	})
+}
+
+// LoadKeysContext is like LoadKeys but generates missing keys with
+// NewDestinationContext, so ctx can abort the SAM request.
+func LoadKeysContext(ctx context.Context, r string) (I2PKeys, error) {
+	return loadOrGenerateKeys(r, func() (*I2PKeys, error) {
+		return NewDestinationContext(ctx)
+	})
+}
+
+// loadOrGenerateKeys loads keys from r, or generates them with gen and stores
+// them if the file does not exist.
+func loadOrGenerateKeys(r string, gen func() (*I2PKeys, error)) (I2PKeys, error) {
+	log.Debug("Loading keys from file", "filename", r)
 	exists, err := fileExists(r)
 	if err != nil {
-		log.WithError(err).Error("Error checking if file exists")
+		log.Error("Error checking if file exists", "filename", r, "error", err)
 		return I2PKeys{}, err
 	}
+	if !exists {
+		lock, err := lockFile(r + lockFileSuffix)
+		if err != nil {
+			log.Error("Error locking key file", "filename", r, "error", err)
+			return I2PKeys{}, err
+		}
+		defer lock.unlock()
+
+		// Another process may have created the keys while we waited
+		if exists, err = fileExists(r); err != nil {
+			return I2PKeys{}, err
+		}
+	}
 	if !exists {
 		// File doesn't exist so we'll generate new keys
-		log.WithError(err).Debug("File does not exist, attempting to generate new keys")
-		k, err := NewDestination()
+		log.Debug("File does not exist, attempting to generate new keys", "filename", r)
+		k, err := gen()
 		if err != nil {
-			log.WithError(err).Error("Error generating new keys")
+			log.Error("Error generating new keys", "error", err)
 			return I2PKeys{}, err
 		}
 		// Save the new keys to the file
 		err = StoreKeys(*k, r)
 		if err != nil {
-			log.WithError(err).Error("Error saving new keys to file")
+			log.Error("Error saving new keys to file", "filename", r, "error", err)
 			return I2PKeys{}, err
 		}
 		return *k, nil
 	}
 	fi, err := os.Open(r)
 	if err != nil {
-		log.WithError(err).WithField("filename", r).Error("Error opening file")
+		log.Error("Error opening file", "filename", r, "error", err)
 		return I2PKeys{}, fmt.Errorf("error opening file: %w", err)
 	}
 	defer fi.Close()
-	log.WithField("filename", r).Debug("File opened successfully")
+	log.Debug("File opened successfully", "filename", r)
 	return LoadKeysIncompat(fi)
 }
@@ -1,173 +1,123 @@
 package i2pkeys
 
 import (
-	"bufio"
 	"context"
 	"fmt"
-	"net"
-	"strings"
 	"time"
 )
 
+// DefaultSAMAddress is the SAM bridge used by the package-level functions. It
+// may also be a Unix socket, such as unix:///run/i2p/sam.sock.
 var DefaultSAMAddress = "127.0.0.1:7656"
 
 const (
 	defaultTimeout  = 30 * time.Second
 	maxResponseSize = 4096
 
-	cmdHello      = "HELLO VERSION MIN=3.1 MAX=3.1\n"
-	cmdGenerate   = "DEST GENERATE SIGNATURE_TYPE=%s\n"
-	responseOK    = "RESULT=OK"
-	pubKeyPrefix  = "PUB="
-	privKeyPrefix = "PRIV="
+	cmdHello    = "HELLO VERSION MIN=%s MAX=%s\n"
+	cmdGenerate = "DEST GENERATE SIGNATURE_TYPE=%s\n"
 )
 
-// samClient handles communication with the SAM bridge
-type samClient struct {
-	addr    string
-	timeout time.Duration
+// NewDestination generates a new I2P destination using the SAM bridge at
+// DefaultSAMAddress. Use a SAMClient to talk to another bridge.
+// keyType is a signature type code or name accepted by ParseSigType.
+func NewDestination(keyType ...string) (*I2PKeys, error) {
+	return NewSAMClient().NewDestination(keyType...)
 }
 
-// newSAMClient creates a new SAM client with optional configuration
-func newSAMClient(options ...func(*samClient)) *samClient {
-	client := &samClient{
-		addr:    DefaultSAMAddress,
-		timeout: defaultTimeout,
-	}
-
-	for _, opt := range options {
-		opt(client)
-	}
+// NewDestinationContext is like NewDestination but aborts when ctx is
+// cancelled or its deadline passes.
+func NewDestinationContext(ctx context.Context, keyType ...string) (*I2PKeys, error) {
+	return NewSAMClient().NewDestinationContext(ctx, keyType...)
+}
 
-	return client
+// NewDestinationWithSigType generates a new I2P destination with the given
+// signature type using the SAM bridge at DefaultSAMAddress.
+func NewDestinationWithSigType(ctx context.Context, sigType SigType) (*I2PKeys, error) {
+	return NewSAMClient().NewDestinationWithSigType(ctx, sigType)
 }
 
 // NewDestination generates a new I2P destination using the SAM bridge.
-// This is the only public function that external code should use.
-func NewDestination(keyType ...string) (*I2PKeys, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
-	defer cancel()
-	if keyType == nil {
-		keyType = []string{"7"}
-	}
-
-	client := newSAMClient()
-	return client.generateDestination(ctx, keyType[0])
+// keyType is a signature type code or name accepted by ParseSigType, such as
+// "7" or "EdDSA_SHA512_Ed25519", and defaults to Ed25519. Prefer
+// NewDestinationWithSigType.
+func (c *SAMClient) NewDestination(keyType ...string) (*I2PKeys, error) {
+	return c.NewDestinationContext(context.Background(), keyType...)
 }
 
-// generateDestination handles the key generation process
-func (c *samClient) generateDestination(ctx context.Context, keyType string) (*I2PKeys, error) {
-	conn, err := c.dial(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("connecting to SAM bridge: %w", err)
-	}
-	// Ensure connection is always closed, even on error paths
-	defer func() {
-		if closeErr := conn.Close(); closeErr != nil {
-			log.WithError(closeErr).Debug("Error closing SAM connection")
+// NewDestinationContext is like NewDestination but aborts when ctx is
+// cancelled or its deadline passes. The client timeout still applies.
+func (c *SAMClient) NewDestinationContext(ctx context.Context, keyType ...string) (*I2PKeys, error) {
+	sigType := SigTypeEd25519
+	if len(keyType) > 0 {
+		var err error
+		if sigType, err = ParseSigType(keyType[0]); err != nil {
+			return nil, err
 		}
-	}()
-
-	if err := c.handshake(ctx, conn); err != nil {
-		return nil, fmt.Errorf("SAM handshake failed: %w", err)
 	}
+	return c.NewDestinationWithSigType(ctx, sigType)
+}
 
-	keys, err := c.generateKeys(ctx, conn, keyType)
-	if err != nil {
-		return nil, fmt.Errorf("generating keys: %w", err)
+// NewDestinationWithSigType generates a new I2P destination with the given
+// signature type, such as SigTypeEd25519. It aborts when ctx is cancelled or
+// its deadline passes, and the client timeout still applies.
+func (c *SAMClient) NewDestinationWithSigType(ctx context.Context, sigType SigType) (*I2PKeys, error) {
+	if !sigType.IsKnown() {
+		return nil, fmt.Errorf("%w: unknown signature type %d", ErrInvalidKeyType, sigType)
 	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
-	return keys, nil
+	return c.generateDestination(ctx, sigType.String())
 }
 
-func (c *samClient) dial(ctx context.Context) (net.Conn, error) {
-	dialer := &net.Dialer{Timeout: c.timeout}
-	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+// generateDestination handles the key generation process
+func (c *SAMClient) generateDestination(ctx context.Context, keyType string) (*I2PKeys, error) {
+	conn, err := c.connect(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("dialing SAM bridge: %w", err)
-	}
-	return conn, nil
-}
-
-func (c *samClient) handshake(ctx context.Context, conn net.Conn) error {
-	if err := c.writeCommand(conn, cmdHello); err != nil {
-		return err
+		return nil, err
 	}
+	// Ensure connection is always closed, even on error paths
+	defer c.close(conn)
 
-	response, err := c.readResponse(conn)
+	keys, err := c.generateKeys(ctx, conn, keyType)
 	if err != nil {
-		return err
-	}
-
-	if !strings.Contains(response, responseOK) {
-		return fmt.Errorf("unexpected SAM response: %s", response)
+		return nil, fmt.Errorf("generating keys: %w", contextError(ctx, err))
 	}
 
-	return nil
+	return keys, nil
 }
 
-func (c *samClient) generateKeys(ctx context.Context, conn net.Conn, keyType string) (*I2PKeys, error) {
+func (c *SAMClient) generateKeys(ctx context.Context, conn *samConn, keyType string) (*I2PKeys, error) {
 	cmdGenerate := fmt.Sprintf(cmdGenerate, keyType)
 	if err := c.writeCommand(conn, cmdGenerate); err != nil {
 		return nil, err
 	}
 
-	response, err := c.readResponse(conn)
+	reply, err := c.readReply(conn, "DEST", "REPLY")
 	if err != nil {
 		return nil, err
 	}
-
-	pub, priv, err := parseKeyResponse(response)
-	if err != nil {
+	if err := reply.err(); err != nil {
 		return nil, err
 	}
-	log.Println("Generated keys:", pub, priv)
+
+	// The reply carries the private keys, so it is never logged or
+	// included in errors
+	pub, priv := reply.Values["PUB"], reply.Values["PRIV"]
 	if len(pub) == 0 || len(priv) == 0 {
-		return nil, fmt.Errorf("invalid key response: %s", response)
+		return nil, fmt.Errorf("%w: DEST REPLY without keys", ErrSAMProtocol)
 	}
 	if len(pub) > maxResponseSize || len(priv) > maxResponseSize {
-		return nil, fmt.Errorf("key response too large: %s", response)
+		return nil, fmt.Errorf("key response too large: %d bytes", len(pub)+len(priv))
 	}
 	if len(pub) < 128 || len(priv) < 128 {
-		return nil, fmt.Errorf("key response too small: %s", response)
+		return nil, fmt.Errorf("key response too small: %d bytes", len(pub)+len(priv))
 	}
+	c.logger.Debug("Generated keys", "sam", c.addr, "address", I2PAddr(pub).Base32())
 
 	return &I2PKeys{
 		Address: I2PAddr(pub),
 		Both:    pub + priv,
 	}, nil
 }
-
-func (c *samClient) writeCommand(conn net.Conn, cmd string) error {
-	_, err := conn.Write([]byte(cmd))
-	if err != nil {
-		return fmt.Errorf("writing command: %w", err)
-	}
-	return nil
-}
-
-func (c *samClient) readResponse(conn net.Conn) (string, error) {
-	reader := bufio.NewReader(conn)
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return "", fmt.Errorf("reading response: %w", err)
-	}
-	return strings.TrimSpace(response), nil
-}
-
-func parseKeyResponse(response string) (pub, priv string, err error) {
-	parts := strings.Split(response, privKeyPrefix)
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid key response format")
-	}
-
-	pubParts := strings.Split(parts[0], pubKeyPrefix)
-	if len(pubParts) != 2 {
-		return "", "", fmt.Errorf("invalid public key format")
-	}
-
-	pub = strings.TrimSpace(pubParts[1])
-	priv = strings.TrimSpace(parts[1])
-
-	return pub, priv, nil
-}
@@ -0,0 +1,167 @@
+package i2pkeys
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// paddingPatternSize is the length of the random pattern repeated through
+// destination padding, which keeps new destinations compressible.
+const paddingPatternSize = 32
+
+// LocalKeyOption configures NewLocalDestination.
+type LocalKeyOption func(*localKeyConfig)
+
+type localKeyConfig struct {
+	sigType SigType
+	encType EncType
+	rand    io.Reader
+}
+
+// WithSigType selects the signing algorithm of the generated destination.
+func WithSigType(t SigType) LocalKeyOption {
+	return func(c *localKeyConfig) {
+		c.sigType = t
+	}
+}
+
+// WithEncType selects the encryption algorithm of the generated destination.
+func WithEncType(t EncType) LocalKeyOption {
+	return func(c *localKeyConfig) {
+		c.encType = t
+	}
+}
+
+// WithRandom sets the source of randomness used for key generation.
+func WithRandom(r io.Reader) LocalKeyOption {
+	return func(c *localKeyConfig) {
+		c.rand = r
+	}
+}
+
+// NewLocalDestination generates a new I2P destination in-process, without a
+// SAM bridge. By default it creates an Ed25519 signing key and an X25519
+// encryption key, the same types a router uses for new destinations. The
+// returned keys use the same format as NewDestination and can be used
+// interchangeably with router-generated keys.
+func NewLocalDestination(options ...LocalKeyOption) (*I2PKeys, error) {
+	cfg := &localKeyConfig{
+		sigType: SigTypeEd25519,
+		encType: EncTypeX25519,
+		rand:    rand.Reader,
+	}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	log.Debug("Generating local destination", "sigtype", cfg.sigType, "enctype", cfg.encType)
+
+	sigPub, sigPriv, err := generateSigningKeys(cfg.rand, cfg.sigType)
+	if err != nil {
+		return nil, fmt.Errorf("generating signing keys: %w", err)
+	}
+	encPub, encPriv, err := generateEncryptionKeys(cfg.rand, cfg.encType)
+	if err != nil {
+		return nil, fmt.Errorf("generating encryption keys: %w", err)
+	}
+
+	dest, err := assembleDestination(cfg.rand, cfg.sigType, sigPub, cfg.encType, encPub)
+	if err != nil {
+		return nil, err
+	}
+
+	priv := dest.Bytes()
+	priv = append(priv, encPriv...)
+	priv = append(priv, sigPriv...)
+
+	pub := i2pB64enc.EncodeToString(dest.Bytes())
+	return &I2PKeys{
+		Address: I2PAddr(pub),
+		Both:    pub + i2pB64enc.EncodeToString(priv),
+	}, nil
+}
+
+// generateSigningKeys returns a signing key pair in the encoding used by
+// destinations and private key files.
+func generateSigningKeys(rand io.Reader, t SigType) (pub, priv []byte, err error) {
+	switch t {
+	case SigTypeEd25519:
+		pubKey, privKey, err := ed25519.GenerateKey(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pubKey, privKey.Seed(), nil
+	case SigTypeECDSAP256, SigTypeECDSAP384, SigTypeECDSAP521:
+		return generateECDSAKeys(rand, t)
+	case SigTypeDSASHA1:
+		return generateDSAKeys(rand)
+	case SigTypeRSA2048, SigTypeRSA3072, SigTypeRSA4096:
+		return generateRSAKeys(rand, t)
+	case SigTypeRedDSA25519:
+		return generateRedDSAKeys(rand)
+	default:
+		return nil, nil, fmt.Errorf("%w: cannot generate %s signing keys", ErrInvalidKeyType, t)
+	}
+}
+
+// generateEncryptionKeys returns an encryption key pair in the encoding used
+// by destinations and private key files.
+func generateEncryptionKeys(rand io.Reader, t EncType) (pub, priv []byte, err error) {
+	switch t {
+	case EncTypeX25519:
+		privKey, err := ecdh.X25519().GenerateKey(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		return privKey.PublicKey().Bytes(), privKey.Bytes(), nil
+	case EncTypeElGamal:
+		return generateElGamalKeys(rand)
+	default:
+		return nil, nil, fmt.Errorf("%w: cannot generate %s encryption keys", ErrInvalidKeyType, t)
+	}
+}
+
+// assembleDestination lays out public keys in a destination. The encryption
+// key starts the public key area, the signing key ends the signing key area,
+// and the space between them is filled with random padding. A KEY
+// certificate is used unless the types are the DSA_SHA1 and ElGamal defaults.
+func assembleDestination(rand io.Reader, sigType SigType, sigPub []byte, encType EncType, encPub []byte) (*Destination, error) {
+	if len(sigPub) != sigType.PublicKeyLen() {
+		return nil, fmt.Errorf("%w: %s public key is %d bytes, want %d",
+			ErrInvalidKeyType, sigType, len(sigPub), sigType.PublicKeyLen())
+	}
+	if len(encPub) != encType.PublicKeyLen() {
+		return nil, fmt.Errorf("%w: %s public key is %d bytes, want %d",
+			ErrInvalidKeyType, encType, len(encPub), encType.PublicKeyLen())
+	}
+
+	d := &Destination{sigType: sigType, encType: encType}
+	encLen := min(len(encPub), PublicKeyAreaSize)
+	sigLen := min(len(sigPub), SigningKeyAreaSize)
+	copy(d.keys[:encLen], encPub)
+	copy(d.keys[KeysAndCertSize-sigLen:], sigPub[:sigLen])
+
+	if padding := d.keys[encLen : KeysAndCertSize-sigLen]; len(padding) > 0 {
+		pattern := make([]byte, paddingPatternSize)
+		if _, err := io.ReadFull(rand, pattern); err != nil {
+			return nil, fmt.Errorf("generating padding: %w", err)
+		}
+		for i := range padding {
+			padding[i] = pattern[i%paddingPatternSize]
+		}
+	}
+
+	if sigType == SigTypeDSASHA1 && encType == EncTypeElGamal {
+		d.cert = Certificate{Type: CertTypeNull}
+		return d, nil
+	}
+	d.cert = KeyCertificate{
+		SigType:             sigType,
+		EncType:             encType,
+		ExcessSigningKey:    sigPub[sigLen:],
+		ExcessEncryptionKey: encPub[encLen:],
+	}.Certificate()
+	return d, nil
+}
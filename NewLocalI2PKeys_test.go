@@ -0,0 +1,83 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func Test_NewLocalDestination(t *testing.T) {
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: '%v'", err)
+	}
+
+	t.Run("Address", func(t *testing.T) {
+		addr, err := NewI2PAddrFromString(keys.Address.Base64())
+		if err != nil {
+			t.Fatalf("Generated address is invalid: '%v'", err)
+		}
+		dest, err := addr.Destination()
+		if err != nil {
+			t.Fatalf("Destination failed: '%v'", err)
+		}
+		if dest.SigType() != SigTypeEd25519 || dest.EncType() != EncTypeX25519 {
+			t.Errorf("Wrong key types. Got %s/%s, want %s/%s",
+				dest.SigType(), dest.EncType(), SigTypeEd25519, EncTypeX25519)
+		}
+		if dest.Certificate().Type != CertTypeKey {
+			t.Errorf("Wrong certificate type. Got %d, want %d", dest.Certificate().Type, CertTypeKey)
+		}
+	})
+
+	t.Run("Both format", func(t *testing.T) {
+		if !strings.HasPrefix(keys.Both, keys.Address.Base64()) {
+			t.Fatal("Both does not start with the address")
+		}
+		priv, err := i2pB64enc.DecodeString(strings.TrimPrefix(keys.Both, keys.Address.Base64()))
+		if err != nil {
+			t.Fatalf("Private key is not valid base64: '%v'", err)
+		}
+		dest, err := keys.Address.Destination()
+		if err != nil {
+			t.Fatalf("Destination failed: '%v'", err)
+		}
+		destBytes := dest.Bytes()
+		if want := len(destBytes) + 32 + 32; len(priv) != want {
+			t.Fatalf("Wrong private key length. Got %d, want %d", len(priv), want)
+		}
+		if !bytes.Equal(priv[:len(destBytes)], destBytes) {
+			t.Error("Private key does not start with the destination")
+		}
+
+		encPriv, err := ecdh.X25519().NewPrivateKey(priv[len(destBytes) : len(destBytes)+32])
+		if err != nil {
+			t.Fatalf("Invalid X25519 private key: '%v'", err)
+		}
+		if !bytes.Equal(encPriv.PublicKey().Bytes(), dest.EncryptionPublicKey()) {
+			t.Error("X25519 private key does not match the destination")
+		}
+		sigPriv := ed25519.NewKeyFromSeed(priv[len(destBytes)+32:])
+		if !bytes.Equal(sigPriv.Public().(ed25519.PublicKey), dest.SigningPublicKey()) {
+			t.Error("Ed25519 private key does not match the destination")
+		}
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		other, err := NewLocalDestination()
+		if err != nil {
+			t.Fatalf("NewLocalDestination failed: '%v'", err)
+		}
+		if other.Address == keys.Address {
+			t.Error("Two generated destinations are identical")
+		}
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		if _, err := NewLocalDestination(WithSigType(SigType(9))); err == nil {
+			t.Error("NewLocalDestination should have failed for an unsupported signature type")
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// offlineHeaderSize is the size of the expiration and transient signature
+// type fields opening an offline signature section.
+const offlineHeaderSize = 6
+
+var ErrInvalidPrivateKey = errors.New("invalid private key")
+
+// OfflineSignature is the optional section of a private key file used by
+// destinations whose long-term signing key is kept offline. The transient key
+// is authorized by a signature from the destination's signing key and is the
+// key actually used for signing.
+type OfflineSignature struct {
+	Expires             time.Time
+	TransientSigType    SigType
+	TransientPublicKey  []byte
+	Signature           []byte
+	TransientPrivateKey []byte
+}
+
+// signedBytes returns the fields covered by the offline signature.
+func (o *OfflineSignature) signedBytes() []byte {
+	out := make([]byte, offlineHeaderSize, offlineHeaderSize+len(o.TransientPublicKey))
+	binary.BigEndian.PutUint32(out[0:4], uint32(o.Expires.Unix()))
+	binary.BigEndian.PutUint16(out[4:6], uint16(o.TransientSigType))
+	return append(out, o.TransientPublicKey...)
+}
+
+// PrivateKeyFile is the parsed private half of I2PKeys, laid out as the SAM
+// PRIV value and router private key files: the destination, the encryption
+// private key, the signing private key and an optional offline signature
+// section. Key lengths are determined by the destination's certificate.
+type PrivateKeyFile struct {
+	Destination          *Destination
+	EncryptionPrivateKey []byte
+	SigningPrivateKey    []byte
+	Offline              *OfflineSignature
+}
+
+// ParsePrivateKeyFile parses the binary private key layout. An all-zero
+// signing private key marks the start of an offline signature section.
+func ParsePrivateKeyFile(data []byte) (*PrivateKeyFile, error) {
+	if len(data) < MinDestinationSize {
+		return nil, fmt.Errorf("%w: got %d bytes, want at least %d",
+			ErrInvalidPrivateKey, len(data), MinDestinationSize)
+	}
+	certLen := int(binary.BigEndian.Uint16(data[KeysAndCertSize+1 : MinDestinationSize]))
+	destLen := MinDestinationSize + certLen
+	if len(data) < destLen {
+		return nil, fmt.Errorf("%w: truncated destination", ErrInvalidPrivateKey)
+	}
+	dest, err := ParseDestination(data[:destLen])
+	if err != nil {
+		return nil, err
+	}
+
+	// Private key lengths are only known for known types
+	if !dest.SigType().IsKnown() || !dest.EncType().IsKnown() {
+		return nil, fmt.Errorf("%w: unknown key types %d/%d", ErrInvalidKeyType, dest.SigType(), dest.EncType())
+	}
+
+	p := &PrivateKeyFile{Destination: dest}
+	rest := data[destLen:]
+	if p.EncryptionPrivateKey, rest, err = readPrivateField(rest, dest.EncType().PrivateKeyLen(), "encryption private key"); err != nil {
+		return nil, err
+	}
+	if p.SigningPrivateKey, rest, err = readPrivateField(rest, dest.SigType().PrivateKeyLen(), "signing private key"); err != nil {
+		return nil, err
+	}
+
+	if isZero(p.SigningPrivateKey) {
+		if p.Offline, rest, err = parseOfflineSignature(rest, dest.SigType()); err != nil {
+			return nil, err
+		}
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: %d unexpected trailing bytes", ErrInvalidPrivateKey, len(rest))
+	}
+	return p, nil
+}
+
+func parseOfflineSignature(data []byte, destSigType SigType) (*OfflineSignature, []byte, error) {
+	if len(data) < offlineHeaderSize {
+		return nil, nil, fmt.Errorf("%w: truncated offline signature section", ErrInvalidPrivateKey)
+	}
+	o := &OfflineSignature{
+		Expires:          time.Unix(int64(binary.BigEndian.Uint32(data[0:4])), 0).UTC(),
+		TransientSigType: SigType(binary.BigEndian.Uint16(data[4:6])),
+	}
+	if !o.TransientSigType.IsKnown() {
+		return nil, nil, fmt.Errorf("%w: unknown transient signature type %d", ErrInvalidPrivateKey, o.TransientSigType)
+	}
+
+	var err error
+	rest := data[offlineHeaderSize:]
+	if o.TransientPublicKey, rest, err = readPrivateField(rest, o.TransientSigType.PublicKeyLen(), "transient public key"); err != nil {
+		return nil, nil, err
+	}
+	if o.Signature, rest, err = readPrivateField(rest, destSigType.SignatureLen(), "offline signature"); err != nil {
+		return nil, nil, err
+	}
+	if o.TransientPrivateKey, rest, err = readPrivateField(rest, o.TransientSigType.PrivateKeyLen(), "transient private key"); err != nil {
+		return nil, nil, err
+	}
+	return o, rest, nil
+}
+
+// readPrivateField splits a fixed-length field off the front of data.
+func readPrivateField(data []byte, n int, name string) (field, rest []byte, err error) {
+	if len(data) < n {
+		return nil, nil, fmt.Errorf("%w: truncated %s: got %d bytes, want %d", ErrInvalidPrivateKey, name, len(data), n)
+	}
+	return append([]byte(nil), data[:n]...), data[n:], nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Bytes returns the binary private key layout.
+func (p *PrivateKeyFile) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(p.Destination.Bytes())
+	buf.Write(p.EncryptionPrivateKey)
+	buf.Write(p.SigningPrivateKey)
+	if p.Offline != nil {
+		buf.Write(p.Offline.signedBytes())
+		buf.Write(p.Offline.Signature)
+		buf.Write(p.Offline.TransientPrivateKey)
+	}
+	return buf.Bytes()
+}
+
+// SigType returns the type of the key used for signing, which is the
+// transient key type for destinations with an offline signature.
+func (p *PrivateKeyFile) SigType() SigType {
+	if p.Offline != nil {
+		return p.Offline.TransientSigType
+	}
+	return p.Destination.SigType()
+}
+
+// SigningKey returns the private key used for signing, which is the
+// transient key for destinations with an offline signature.
+func (p *PrivateKeyFile) SigningKey() []byte {
+	if p.Offline != nil {
+		return append([]byte(nil), p.Offline.TransientPrivateKey...)
+	}
+	return append([]byte(nil), p.SigningPrivateKey...)
+}
+
+// signingPublicKey returns the public half of SigningKey.
+func (p *PrivateKeyFile) signingPublicKey() []byte {
+	if p.Offline != nil {
+		return append([]byte(nil), p.Offline.TransientPublicKey...)
+	}
+	return p.Destination.SigningPublicKey()
+}
+
+// I2PKeys returns the keys in the format produced by NewDestination.
+func (p *PrivateKeyFile) I2PKeys() (I2PKeys, error) {
+	addr, err := p.Destination.Addr()
+	if err != nil {
+		return I2PKeys{}, err
+	}
+	return I2PKeys{
+		Address: addr,
+		Both:    addr.Base64() + i2pB64enc.EncodeToString(p.Bytes()),
+	}, nil
+}
+
+// PrivateKeyFile parses the private portion of the keys.
+func (k I2PKeys) PrivateKeyFile() (*PrivateKeyFile, error) {
+	raw := k.Private()
+	if raw == nil {
+		return nil, fmt.Errorf("%w: could not extract private key", ErrInvalidPrivateKey)
+	}
+	p, err := ParsePrivateKeyFile(raw)
+	if err != nil {
+		return nil, err
+	}
+	addrBytes, err := k.Address.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(p.Destination.Bytes(), addrBytes) {
+		return nil, fmt.Errorf("%w: private key does not belong to address", ErrInvalidPrivateKey)
+	}
+	return p, nil
+}
+
+// SigningPrivateKey returns the raw signing private key. For destinations
+// with an offline signature this is the transient key.
+func (k I2PKeys) SigningPrivateKey() ([]byte, error) {
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	return p.SigningKey(), nil
+}
+
+// EncryptionPrivateKey returns the raw encryption private key.
+func (k I2PKeys) EncryptionPrivateKey() ([]byte, error) {
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), p.EncryptionPrivateKey...), nil
+}
+
+// ReadPrivateKeyFile reads keys from the binary private key file format used
+// by the Java router (eepPriv.dat, written by PrivateKeyFile) and by i2pd
+// (.dat key files). Offline signature sections are preserved.
+func ReadPrivateKeyFile(r io.Reader) (I2PKeys, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return I2PKeys{}, fmt.Errorf("error copying from reader: %w", err)
+	}
+	p, err := ParsePrivateKeyFile(data)
+	if err != nil {
+		return I2PKeys{}, err
+	}
+	return p.I2PKeys()
+}
+
+// WritePrivateKeyFile writes keys in the binary private key file format read
+// by the Java router and i2pd.
+func WritePrivateKeyFile(w io.Writer, k I2PKeys) error {
+	p, err := k.PrivateKeyFile()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(p.Bytes()); err != nil {
+		log.Error("Error writing keys", "error", err)
+		return fmt.Errorf("error writing keys: %w", err)
+	}
+	return nil
+}
+
+// LoadPrivateKeyFile reads keys from a binary private key file.
+func LoadPrivateKeyFile(path string) (I2PKeys, error) {
+	log.Debug("Loading binary private key file", "filename", path)
+	f, err := os.Open(path)
+	if err != nil {
+		log.Error("Error opening file", "filename", path, "error", err)
+		return I2PKeys{}, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+	return ReadPrivateKeyFile(f)
+}
+
+// StorePrivateKeyFile writes keys to a binary private key file, following the
+// same overwrite and backup rules as StoreKeysWithOptions.
+func StorePrivateKeyFile(k I2PKeys, path string, options ...StoreOption) error {
+	log.Debug("Storing binary private key file", "filename", path)
+	var buf bytes.Buffer
+	if err := WritePrivateKeyFile(&buf, k); err != nil {
+		return err
+	}
+	return storeKeyData(k, path, buf.Bytes(), loadKeyData, options)
+}
@@ -0,0 +1,200 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_PrivateKeyFileInterop(t *testing.T) {
+	// Files without a router are assembled from the specification by
+	// genprivatekeys.go. Router captures are skipped until they are checked
+	// in; testdata/README.md describes how to make them.
+	cases := []struct {
+		file    string
+		router  string
+		sigType SigType
+		encType EncType
+		offline bool
+		size    int
+	}{
+		{"ed25519-elgamal.dat", "", SigTypeEd25519, EncTypeElGamal, false, 679},
+		{"ed25519-x25519.dat", "", SigTypeEd25519, EncTypeX25519, false, 455},
+		{"ed25519-elgamal-offline.dat", "", SigTypeEd25519, EncTypeElGamal, true, 813},
+		{"java-ed25519-elgamal.dat", "Java", SigTypeEd25519, EncTypeElGamal, false, 679},
+		{"i2pd-ed25519-elgamal-offline.dat", "i2pd", SigTypeEd25519, EncTypeElGamal, true, 813},
+	}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.file))
+			if os.IsNotExist(err) && tc.router != "" {
+				t.Skipf("%s capture %s is not checked in yet", tc.router, tc.file)
+			}
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if len(data) != tc.size {
+				t.Errorf("Wrong fixture size. Got %d, want %d", len(data), tc.size)
+			}
+			p, err := ParsePrivateKeyFile(data)
+			if err != nil {
+				t.Fatalf("ParsePrivateKeyFile failed: %v", err)
+			}
+			if p.Destination.SigType() != tc.sigType || p.Destination.EncType() != tc.encType {
+				t.Errorf("Wrong key types. Got %s/%s, want %s/%s",
+					p.Destination.SigType(), p.Destination.EncType(), tc.sigType, tc.encType)
+			}
+			if err := p.Destination.ValidateEncryptionKey(); err != nil {
+				t.Errorf("Invalid encryption key: %v", err)
+			}
+			if (p.Offline != nil) != tc.offline {
+				t.Fatalf("Offline section parsed: %v, want %v", p.Offline != nil, tc.offline)
+			}
+			if p.Offline != nil {
+				pub := ed25519.PublicKey(p.Destination.SigningPublicKey())
+				if !ed25519.Verify(pub, p.Offline.signedBytes(), p.Offline.Signature) {
+					t.Error("Offline signature does not verify")
+				}
+			}
+
+			keys, err := ReadPrivateKeyFile(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadPrivateKeyFile failed: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := WritePrivateKeyFile(&buf, keys); err != nil {
+				t.Fatalf("WritePrivateKeyFile failed: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), data) {
+				t.Error("Written file differs from the fixture")
+			}
+		})
+	}
+}
+
+func Test_PrivateKeyFile(t *testing.T) {
+	// Sizes of the DSA/ElGamal and Ed25519/X25519 files this package writes
+	cases := []struct {
+		name    string
+		options []LocalKeyOption
+		size    int
+	}{
+		{"DSA_SHA1 ElGamal", []LocalKeyOption{WithSigType(SigTypeDSASHA1), WithEncType(EncTypeElGamal)}, 663},
+		{"Ed25519 X25519", nil, 455},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := NewLocalDestination(tc.options...)
+			if err != nil {
+				t.Fatalf("NewLocalDestination failed: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := WritePrivateKeyFile(&buf, *keys); err != nil {
+				t.Fatalf("WritePrivateKeyFile failed: %v", err)
+			}
+			if buf.Len() != tc.size {
+				t.Errorf("Wrong file size. Got %d, want %d", buf.Len(), tc.size)
+			}
+			addr, err := keys.Address.ToBytes()
+			if err != nil {
+				t.Fatalf("ToBytes failed: %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), addr) {
+				t.Error("File does not start with the destination")
+			}
+
+			loaded, err := ReadPrivateKeyFile(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("ReadPrivateKeyFile failed: %v", err)
+			}
+			if loaded != *keys {
+				t.Error("Loaded keys do not match the original")
+			}
+		})
+	}
+
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+
+	t.Run("Offline signature", func(t *testing.T) {
+		p, err := keys.PrivateKeyFile()
+		if err != nil {
+			t.Fatalf("PrivateKeyFile failed: %v", err)
+		}
+		transientPub, transientPriv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate transient key: %v", err)
+		}
+		p.Offline = &OfflineSignature{
+			Expires:             time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+			TransientSigType:    SigTypeEd25519,
+			TransientPublicKey:  transientPub,
+			TransientPrivateKey: transientPriv.Seed(),
+		}
+		p.Offline.Signature = ed25519.Sign(ed25519.NewKeyFromSeed(p.SigningPrivateKey), p.Offline.signedBytes())
+		p.SigningPrivateKey = make([]byte, SigTypeEd25519.PrivateKeyLen())
+
+		offlineKeys, err := p.I2PKeys()
+		if err != nil {
+			t.Fatalf("I2PKeys failed: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := WritePrivateKeyFile(&buf, offlineKeys); err != nil {
+			t.Fatalf("WritePrivateKeyFile failed: %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), p.Bytes()) {
+			t.Error("Written file does not match the private key layout")
+		}
+		loaded, err := ReadPrivateKeyFile(&buf)
+		if err != nil {
+			t.Fatalf("ReadPrivateKeyFile failed: %v", err)
+		}
+		if loaded != offlineKeys {
+			t.Error("Loaded keys do not match the original")
+		}
+	})
+
+	t.Run("Files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "eepPriv.dat")
+		if err := StorePrivateKeyFile(*keys, path); err != nil {
+			t.Fatalf("StorePrivateKeyFile failed: %v", err)
+		}
+		loaded, err := LoadPrivateKeyFile(path)
+		if err != nil {
+			t.Fatalf("LoadPrivateKeyFile failed: %v", err)
+		}
+		if loaded != *keys {
+			t.Error("Loaded keys do not match the original")
+		}
+		detected, err := LoadKeys(path)
+		if err != nil {
+			t.Fatalf("LoadKeys failed: %v", err)
+		}
+		if detected != *keys {
+			t.Error("LoadKeys did not detect the binary format")
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Wrong file permissions. Got %o, want 600", perm)
+		}
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WritePrivateKeyFile(&buf, *keys); err != nil {
+			t.Fatalf("WritePrivateKeyFile failed: %v", err)
+		}
+		if _, err := ReadPrivateKeyFile(bytes.NewReader(buf.Bytes()[:buf.Len()-1])); err == nil {
+			t.Error("Expected an error for a truncated file")
+		}
+	})
+}
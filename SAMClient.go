@@ -0,0 +1,343 @@
+package i2pkeys
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSAMVersion is the SAM protocol version requested unless
+	// WithSAMVersion is used.
+	DefaultSAMVersion = "3.1"
+
+	// samAuthVersion is the first SAM version with USER and PASSWORD in HELLO.
+	samAuthVersion = "3.2"
+
+	// unixAddressPrefix marks a SAM address as the path of a Unix socket.
+	unixAddressPrefix = "unix://"
+)
+
+// ErrSAMAuth is returned when the bridge rejects the credentials set with
+// WithAuth. It wraps the I2P_ERROR *SAMError from the HELLO reply. A bridge
+// that requires credentials which were not given fails with the *SAMError
+// alone, since its reply cannot be told apart from other HELLO errors.
+var ErrSAMAuth = errors.New("SAM authentication failed")
+
+// DialFunc opens a connection to the SAM bridge. network and address are
+// derived from the SAM address: "unix" and a socket path for unix:// addresses,
+// "tcp" and host:port otherwise.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// SAMClient talks to a SAM bridge for key generation and naming lookups.
+// Each operation uses its own connection, so a client is safe for concurrent
+// use. Create one with NewSAMClient.
+type SAMClient struct {
+	addr       string
+	timeout    time.Duration
+	dialer     *net.Dialer
+	dialFunc   DialFunc
+	minVersion string
+	maxVersion string
+	user       string
+	password   string
+	tlsConfig  *tls.Config
+	logger     Logger
+}
+
+// SAMOption configures a SAMClient.
+type SAMOption func(*SAMClient)
+
+// WithSAMAddress sets the address of the SAM bridge: host:port for TCP, or
+// unix:///path/to/socket for a Unix domain socket.
+func WithSAMAddress(addr string) SAMOption {
+	return func(c *SAMClient) {
+		c.addr = addr
+	}
+}
+
+// WithTimeout limits how long each operation, including dialing, may take.
+func WithTimeout(timeout time.Duration) SAMOption {
+	return func(c *SAMClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithDialer sets the dialer used to connect to the SAM bridge, for example
+// to bind a local address or set keep-alives.
+func WithDialer(dialer *net.Dialer) SAMOption {
+	return func(c *SAMClient) {
+		c.dialer = dialer
+	}
+}
+
+// WithDialFunc sets a function used to connect to the SAM bridge instead of
+// a net.Dialer, for example to tunnel the connection. It takes precedence
+// over WithDialer.
+func WithDialFunc(dial DialFunc) SAMOption {
+	return func(c *SAMClient) {
+		c.dialFunc = dial
+	}
+}
+
+// WithSAMVersion sets the range of SAM protocol versions offered in HELLO.
+func WithSAMVersion(min, max string) SAMOption {
+	return func(c *SAMClient) {
+		c.minVersion, c.maxVersion = min, max
+	}
+}
+
+// WithAuth sets the username and password sent in HELLO to bridges with
+// authentication enabled. Authentication needs SAM 3.2, so the maximum
+// version offered is raised to 3.2 if it is lower.
+func WithAuth(user, password string) SAMOption {
+	return func(c *SAMClient) {
+		c.user, c.password = user, password
+	}
+}
+
+// WithTLS connects to the bridge over TLS with the given configuration. If
+// config.ServerName is empty, the host of a TCP SAM address is used; set it
+// explicitly for Unix sockets.
+func WithTLS(config *tls.Config) SAMOption {
+	return func(c *SAMClient) {
+		c.tlsConfig = config
+	}
+}
+
+// WithLogger sets the logger used by the client instead of the package
+// logger.
+func WithLogger(logger Logger) SAMOption {
+	return func(c *SAMClient) {
+		c.logger = logger
+	}
+}
+
+// NewSAMClient creates a SAM client. By default it connects to
+// DefaultSAMAddress, as set when the client is created, with a 30 second
+// timeout and SAM version 3.1.
+func NewSAMClient(options ...SAMOption) *SAMClient {
+	client := &SAMClient{
+		addr:       DefaultSAMAddress,
+		timeout:    defaultTimeout,
+		minVersion: DefaultSAMVersion,
+		maxVersion: DefaultSAMVersion,
+		logger:     log,
+	}
+
+	for _, opt := range options {
+		opt(client)
+	}
+	if client.logger == nil {
+		client.logger = NopLogger
+	}
+	if client.user != "" && compareSAMVersions(client.maxVersion, samAuthVersion) < 0 {
+		client.maxVersion = samAuthVersion
+	}
+
+	return client
+}
+
+// Addr returns the address of the SAM bridge.
+func (c *SAMClient) Addr() string {
+	return c.addr
+}
+
+// withTimeout bounds ctx by the client timeout. The earlier of the two
+// deadlines applies.
+func (c *SAMClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// samConn is a connection to the bridge bound to the context of the
+// operation using it.
+type samConn struct {
+	net.Conn
+	r    *bufio.Reader
+	stop func() bool
+}
+
+// connect dials the bridge and completes the HELLO handshake. The deadline of
+// ctx applies to every read and write, and cancelling ctx interrupts any
+// blocked read or write.
+func (c *SAMClient) connect(ctx context.Context) (*samConn, error) {
+	netConn, err := c.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to SAM bridge: %w", contextError(ctx, err))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	}
+	conn := &samConn{
+		Conn: netConn,
+		r:    bufio.NewReader(netConn),
+		stop: context.AfterFunc(ctx, func() {
+			// A deadline in the past unblocks pending reads and writes
+			netConn.SetDeadline(time.Unix(1, 0))
+		}),
+	}
+	if err := c.handshake(ctx, conn); err != nil {
+		c.close(conn)
+		return nil, fmt.Errorf("SAM handshake failed: %w", contextError(ctx, err))
+	}
+	return conn, nil
+}
+
+func (c *SAMClient) close(conn *samConn) {
+	conn.stop()
+	if err := conn.Close(); err != nil {
+		c.logger.Debug("Error closing SAM connection", "sam", c.addr, "error", err)
+	}
+}
+
+// contextError returns the context's error if it was cancelled or expired,
+// which is the cause of any I/O error seen after that point. The connection
+// deadline can fire just before the context notices its own.
+func contextError(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+			ctxErr = context.DeadlineExceeded
+		}
+	}
+	if ctxErr != nil {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
+
+// samNetwork splits a SAM address into the network and address to dial.
+func samNetwork(addr string) (network, address string) {
+	if path, ok := strings.CutPrefix(addr, unixAddressPrefix); ok {
+		return "unix", path
+	}
+	return "tcp", strings.TrimPrefix(addr, "tcp://")
+}
+
+func (c *SAMClient) dial(ctx context.Context) (net.Conn, error) {
+	dial := c.dialFunc
+	if dial == nil {
+		dialer := c.dialer
+		if dialer == nil {
+			dialer = &net.Dialer{Timeout: c.timeout}
+		}
+		dial = dialer.DialContext
+	}
+	network, address := samNetwork(c.addr)
+	conn, err := dial(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("dialing SAM bridge: %w", err)
+	}
+	if c.tlsConfig == nil {
+		return conn, nil
+	}
+
+	config := c.tlsConfig
+	if config.ServerName == "" && network == "tcp" {
+		config = config.Clone()
+		config.ServerName, _, _ = net.SplitHostPort(address)
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with SAM bridge: %w", err)
+	}
+	return tlsConn, nil
+}
+
+func (c *SAMClient) handshake(ctx context.Context, conn *samConn) error {
+	if err := checkSAMValue("minimum SAM version", c.minVersion); err != nil {
+		return err
+	}
+	if err := checkSAMValue("maximum SAM version", c.maxVersion); err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf(cmdHello, c.minVersion, c.maxVersion)
+	if c.user != "" {
+		user, err := quoteSAMValue("user", c.user)
+		if err != nil {
+			return err
+		}
+		password, err := quoteSAMValue("password", c.password)
+		if err != nil {
+			return err
+		}
+		// The command holds the password, so it must never be logged
+		cmd = strings.TrimSuffix(cmd, "\n") + " USER=" + user + " PASSWORD=" + password + "\n"
+	}
+	if err := c.writeCommand(conn, cmd); err != nil {
+		return err
+	}
+
+	reply, err := c.readReply(conn, "HELLO", "REPLY")
+	if err != nil {
+		return err
+	}
+	if err := reply.err(); err != nil {
+		// Bridges answer a wrong password with I2P_ERROR, as they do many other
+		// HELLO failures, so only blame credentials that were sent
+		var samErr *SAMError
+		if c.user != "" && errors.As(err, &samErr) && samErr.Result == "I2P_ERROR" {
+			return fmt.Errorf("%w: %w", ErrSAMAuth, err)
+		}
+		return err
+	}
+	if _, ok := reply.Values["VERSION"]; !ok {
+		return fmt.Errorf("%w: HELLO REPLY without VERSION", ErrSAMProtocol)
+	}
+
+	c.logger.Debug("SAM handshake complete", "sam", c.addr, "version", reply.Values["VERSION"])
+	return nil
+}
+
+func (c *SAMClient) writeCommand(conn *samConn, cmd string) error {
+	_, err := conn.Write([]byte(cmd))
+	if err != nil {
+		return fmt.Errorf("writing command: %w", err)
+	}
+	return nil
+}
+
+// readReply reads the next reply line and checks that it has the expected
+// topic and type. The RESULT is left for the caller to check.
+func (c *SAMClient) readReply(conn *samConn, topic, typ string) (*samReply, error) {
+	line, err := readSAMLine(conn.r)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	reply, err := parseSAMReply(line)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Topic != topic || reply.Type != typ {
+		return nil, fmt.Errorf("%w: got %s %s, want %s %s", ErrSAMProtocol, reply.Topic, reply.Type, topic, typ)
+	}
+	return reply, nil
+}
+
+// compareSAMVersions compares two major.minor version strings, treating
+// unparsable parts as zero.
+func compareSAMVersions(a, b string) int {
+	parse := func(v string) (int, int) {
+		major, minor, _ := strings.Cut(v, ".")
+		ma, _ := strconv.Atoi(major)
+		mi, _ := strconv.Atoi(minor)
+		return ma, mi
+	}
+	aMajor, aMinor := parse(a)
+	bMajor, bMinor := parse(b)
+	if aMajor != bMajor {
+		return aMajor - bMajor
+	}
+	return aMinor - bMinor
+}
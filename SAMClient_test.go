@@ -0,0 +1,532 @@
+package i2pkeys
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSAMBridge answers HELLO, DEST GENERATE and NAMING LOOKUP like a SAM
+// bridge, generating keys with NewLocalDestination. Entries in replies
+// override the answer to a command, and split makes every reply arrive in
+// two TCP segments. If user is set, HELLO must carry matching credentials.
+type fakeSAMBridge struct {
+	t        *testing.T
+	listener net.Listener
+	names    map[string]I2PAddr
+	replies  map[string]string
+	split    bool
+	user     string
+	password string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeSAMBridge(t *testing.T) *fakeSAMBridge {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	return serveFakeSAMBridge(t, l)
+}
+
+func serveFakeSAMBridge(t *testing.T, l net.Listener) *fakeSAMBridge {
+	b := &fakeSAMBridge{t: t, listener: l, names: make(map[string]I2PAddr), replies: make(map[string]string)}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeSAMBridge) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// Commands returns the commands received so far.
+func (b *fakeSAMBridge) Commands() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.commands...)
+}
+
+func (b *fakeSAMBridge) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		b.mu.Lock()
+		b.commands = append(b.commands, line)
+		reply, ok := b.replies[line]
+		b.mu.Unlock()
+		if !ok {
+			reply = b.reply(line)
+		}
+		reply += "\n"
+		if b.split {
+			if _, err := conn.Write([]byte(reply[:len(reply)/2])); err != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			reply = reply[len(reply)/2:]
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (b *fakeSAMBridge) reply(line string) string {
+	switch {
+	case strings.HasPrefix(line, "HELLO VERSION"):
+		if b.user != "" {
+			// Commands use the same key=value syntax as replies
+			hello, err := parseSAMReply(line)
+			if err != nil || hello.Values["USER"] != b.user || hello.Values["PASSWORD"] != b.password {
+				return "HELLO REPLY RESULT=I2P_ERROR MESSAGE=\"authorization failed\""
+			}
+			return "HELLO REPLY RESULT=OK VERSION=3.2"
+		}
+		return "HELLO REPLY RESULT=OK VERSION=3.1"
+	case strings.HasPrefix(line, "DEST GENERATE"):
+		keys, err := NewLocalDestination()
+		if err != nil {
+			return "DEST REPLY RESULT=I2P_ERROR"
+		}
+		return "DEST REPLY PUB=" + keys.Address.Base64() + " PRIV=" + strings.TrimPrefix(keys.Both, keys.Address.Base64())
+	case strings.HasPrefix(line, "NAMING LOOKUP NAME="):
+		name := strings.TrimPrefix(line, "NAMING LOOKUP NAME=")
+		if addr, ok := b.names[name]; ok {
+			return "NAMING REPLY RESULT=OK NAME=" + name + " VALUE=" + addr.Base64()
+		}
+		return "NAMING REPLY RESULT=KEY_NOT_FOUND NAME=" + name
+	default:
+		return "SESSION STATUS RESULT=I2P_ERROR MESSAGE=\"unknown command\""
+	}
+}
+
+func Test_SAMClient(t *testing.T) {
+	bridge := newFakeSAMBridge(t)
+	known, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	bridge.names["known.i2p"] = known.Address
+
+	client := NewSAMClient(
+		WithSAMAddress(bridge.Addr()),
+		WithTimeout(5*time.Second),
+		WithDialer(&net.Dialer{KeepAlive: -1}),
+		WithSAMVersion("3.0", "3.3"),
+		WithLogger(NopLogger),
+	)
+
+	t.Run("NewDestination", func(t *testing.T) {
+		keys, err := client.NewDestination(SigTypeEd25519.String())
+		if err != nil {
+			t.Fatalf("NewDestination failed: '%v'", err)
+		}
+		if _, err := keys.PrivateKeyFile(); err != nil {
+			t.Errorf("Generated keys are invalid: '%v'", err)
+		}
+		commands := bridge.Commands()
+		if len(commands) < 2 || commands[0] != "HELLO VERSION MIN=3.0 MAX=3.3" {
+			t.Errorf("Wrong HELLO command: %q", commands)
+		}
+		if commands[1] != "DEST GENERATE SIGNATURE_TYPE=EdDSA_SHA512_Ed25519" {
+			t.Errorf("Wrong DEST GENERATE command: %q", commands[1])
+		}
+	})
+
+	t.Run("Signature types", func(t *testing.T) {
+		keys, err := client.NewDestinationWithSigType(context.Background(), SigTypeEd25519)
+		if err != nil {
+			t.Fatalf("NewDestinationWithSigType failed: '%v'", err)
+		}
+		if sigType, err := keys.Address.SigType(); err != nil || sigType != SigTypeEd25519 {
+			t.Errorf("Wrong signature type %s, '%v'", sigType, err)
+		}
+		sent := len(bridge.Commands())
+		for _, keyType := range []string{"99", "Ed25519"} {
+			if _, err := client.NewDestination(keyType); !errors.Is(err, ErrInvalidKeyType) {
+				t.Errorf("Expected ErrInvalidKeyType for %q, got %v", keyType, err)
+			}
+		}
+		if _, err := client.NewDestinationWithSigType(context.Background(), 99); !errors.Is(err, ErrInvalidKeyType) {
+			t.Errorf("Expected ErrInvalidKeyType, got %v", err)
+		}
+		if len(bridge.Commands()) != sent {
+			t.Error("Invalid signature types were sent to the bridge")
+		}
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		addr, err := client.Lookup("known.i2p")
+		if err != nil {
+			t.Fatalf("Lookup failed: '%v'", err)
+		}
+		if *addr != known.Address {
+			t.Error("Lookup returned the wrong address")
+		}
+		if _, err := client.Lookup("unknown.i2p"); err == nil {
+			t.Error("Expected an error looking up an unknown name")
+		}
+	})
+
+	t.Run("Package functions use DefaultSAMAddress", func(t *testing.T) {
+		old := DefaultSAMAddress
+		DefaultSAMAddress = bridge.Addr()
+		defer func() { DefaultSAMAddress = old }()
+
+		if _, err := NewDestination(); err != nil {
+			t.Errorf("NewDestination failed: '%v'", err)
+		}
+		if _, err := Lookup("known.i2p"); err != nil {
+			t.Errorf("Lookup failed: '%v'", err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		slow := NewSAMClient(WithSAMAddress(newSilentListener(t)), WithTimeout(100*time.Millisecond))
+		start := time.Now()
+		if _, err := slow.Lookup("known.i2p"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Timeout took %v", elapsed)
+		}
+	})
+}
+
+// newSilentListener returns the address of a listener that accepts
+// connections but never answers.
+func newSilentListener(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func Test_SAMClientContext(t *testing.T) {
+	client := NewSAMClient(WithSAMAddress(newSilentListener(t)), WithLogger(NopLogger))
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		start := time.Now()
+		if _, err := client.NewDestinationContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("Cancellation took %v", elapsed)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		if _, err := client.LookupContext(ctx, "known.i2p"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Already cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := LoadKeysContext(ctx, filepath.Join(t.TempDir(), "keys.dat")); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("Working bridge", func(t *testing.T) {
+		bridge := newFakeSAMBridge(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := NewSAMClient(WithSAMAddress(bridge.Addr())).NewDestinationContext(ctx); err != nil {
+			t.Errorf("NewDestinationContext failed: '%v'", err)
+		}
+	})
+}
+
+func Test_SAMClientErrors(t *testing.T) {
+	bridge := newFakeSAMBridge(t)
+	known, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	bridge.names["known.i2p"] = known.Address
+	bridge.replies["NAMING LOOKUP NAME=bad.i2p"] = "NAMING REPLY RESULT=INVALID_KEY NAME=bad.i2p MESSAGE=\"bad \\\"key\\\"\""
+	bridge.replies["NAMING LOOKUP NAME=garbage.i2p"] = "NAMING REPLY RESULT=OK NAME=garbage.i2p VALUE=notadestination"
+	bridge.replies["NAMING LOOKUP NAME=wrong.i2p"] = "DEST REPLY RESULT=OK"
+	bridge.replies["DEST GENERATE SIGNATURE_TYPE=EdDSA_SHA512_Ed25519ph"] = "DEST REPLY RESULT=I2P_ERROR MESSAGE=\"unsupported signature type\""
+	client := NewSAMClient(WithSAMAddress(bridge.Addr()), WithLogger(NopLogger))
+
+	t.Run("Result codes", func(t *testing.T) {
+		if _, err := client.Lookup("unknown.i2p"); !errors.Is(err, ErrNameNotFound) {
+			t.Errorf("Expected ErrNameNotFound, got %v", err)
+		}
+		_, err := client.Lookup("bad.i2p")
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey, got %v", err)
+		}
+		var samErr *SAMError
+		if !errors.As(err, &samErr) || samErr.Message != `bad "key"` {
+			t.Errorf("Expected SAMError with quoted message, got %#v", samErr)
+		}
+		if _, err := client.NewDestinationWithSigType(context.Background(), SigTypeEd25519ph); !errors.Is(err, ErrI2PError) {
+			t.Errorf("Expected ErrI2PError, got %v", err)
+		}
+	})
+
+	t.Run("Malformed replies", func(t *testing.T) {
+		if _, err := client.Lookup("wrong.i2p"); !errors.Is(err, ErrSAMProtocol) {
+			t.Errorf("Expected ErrSAMProtocol, got %v", err)
+		}
+		if _, err := client.Lookup("garbage.i2p"); err == nil {
+			t.Error("Expected an error for an invalid destination")
+		}
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		bridge.replies["HELLO VERSION MIN=4.0 MAX=4.0"] = "HELLO REPLY RESULT=NOVERSION"
+		future := NewSAMClient(WithSAMAddress(bridge.Addr()), WithSAMVersion("4.0", "4.0"))
+		if _, err := future.Lookup("known.i2p"); !errors.Is(err, ErrSAMVersion) {
+			t.Errorf("Expected ErrSAMVersion, got %v", err)
+		}
+
+		// Protocol errors are not credential failures
+		bridge.replies["HELLO VERSION MIN=3.9 MAX=3.9"] = "HELLO REPLY RESULT=I2P_ERROR MESSAGE=\"Must start with HELLO VERSION\""
+		bridge.replies["HELLO VERSION MIN=3.8 MAX=3.8"] = "HELLO REPLY RESULT=UNKNOWN_CODE"
+		for _, version := range []string{"3.9", "3.8"} {
+			odd := NewSAMClient(WithSAMAddress(bridge.Addr()), WithSAMVersion(version, version))
+			var samErr *SAMError
+			if _, err := odd.Lookup("known.i2p"); errors.Is(err, ErrSAMAuth) || !errors.As(err, &samErr) {
+				t.Errorf("Expected a *SAMError without ErrSAMAuth, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Command injection", func(t *testing.T) {
+		if _, err := client.Lookup("known.i2p\nDEST GENERATE"); err == nil {
+			t.Error("Expected an error for a name containing a newline")
+		}
+		sent := len(bridge.Commands())
+		for _, version := range []string{"3.1\nDEST GENERATE", "3.0 MAX=3.3", ""} {
+			injected := NewSAMClient(WithSAMAddress(bridge.Addr()), WithSAMVersion(version, "3.1"))
+			if _, err := injected.Lookup("known.i2p"); err == nil {
+				t.Errorf("Expected an error for SAM version %q", version)
+			}
+		}
+		if len(bridge.Commands()) != sent {
+			t.Errorf("Invalid SAM versions reached the bridge: %q", bridge.Commands()[sent:])
+		}
+	})
+
+	t.Run("Split replies", func(t *testing.T) {
+		split := newFakeSAMBridge(t)
+		split.split = true
+		split.names["known.i2p"] = known.Address
+		client := NewSAMClient(WithSAMAddress(split.Addr()))
+		addr, err := client.Lookup("known.i2p")
+		if err != nil {
+			t.Fatalf("Lookup failed: '%v'", err)
+		}
+		if *addr != known.Address {
+			t.Error("Lookup returned the wrong address")
+		}
+		if _, err := client.NewDestination(); err != nil {
+			t.Errorf("NewDestination failed: '%v'", err)
+		}
+	})
+}
+
+// newTestTLSConfig returns a server configuration with a self-signed
+// certificate for 127.0.0.1 and a client configuration trusting it.
+func newTestTLSConfig(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sam.test"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{RootCAs: pool}
+}
+
+func Test_SAMClientAuthAndTLS(t *testing.T) {
+	serverTLS, clientTLS := newTestTLSConfig(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverTLS)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	bridge := serveFakeSAMBridge(t, l)
+	bridge.user, bridge.password = "alice", `pass word "with" \ specials`
+
+	t.Run("Authenticated TLS", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress(bridge.Addr()), WithTLS(clientTLS), WithAuth(bridge.user, bridge.password))
+		if _, err := client.NewDestination(); err != nil {
+			t.Fatalf("NewDestination failed: '%v'", err)
+		}
+		hello := bridge.Commands()[0]
+		if !strings.HasPrefix(hello, "HELLO VERSION MIN=3.1 MAX=3.2 USER=alice PASSWORD=") {
+			t.Errorf("Wrong HELLO command: %q", hello)
+		}
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress(bridge.Addr()), WithTLS(clientTLS), WithAuth(bridge.user, "wrong"))
+		if _, err := client.Lookup("known.i2p"); !errors.Is(err, ErrSAMAuth) {
+			t.Errorf("Expected ErrSAMAuth, got %v", err)
+		}
+	})
+
+	t.Run("Missing credentials", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress(bridge.Addr()), WithTLS(clientTLS))
+		_, err := client.Lookup("known.i2p")
+		var samErr *SAMError
+		if !errors.As(err, &samErr) || samErr.Result != "I2P_ERROR" {
+			t.Errorf("Expected an I2P_ERROR *SAMError, got %v", err)
+		}
+		if errors.Is(err, ErrSAMAuth) {
+			t.Errorf("Expected no ErrSAMAuth without credentials, got %v", err)
+		}
+	})
+
+	t.Run("Untrusted certificate", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress(bridge.Addr()), WithTLS(&tls.Config{}), WithAuth(bridge.user, bridge.password))
+		var certErr *tls.CertificateVerificationError
+		if _, err := client.NewDestination(); !errors.As(err, &certErr) {
+			t.Errorf("Expected a certificate verification error, got %v", err)
+		}
+	})
+
+	t.Run("Control characters", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress(bridge.Addr()), WithTLS(clientTLS), WithAuth(bridge.user, "pass\nDEST GENERATE"))
+		if _, err := client.NewDestination(); err == nil {
+			t.Error("Expected an error for a password containing a newline")
+		}
+	})
+}
+
+func Test_SAMClientUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sam.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	bridge := serveFakeSAMBridge(t, l)
+	known, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	bridge.names["known.i2p"] = known.Address
+
+	t.Run("Client", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress("unix://"+path), WithTimeout(5*time.Second))
+		if _, err := client.NewDestination(); err != nil {
+			t.Fatalf("NewDestination failed: '%v'", err)
+		}
+		addr, err := client.Lookup("known.i2p")
+		if err != nil {
+			t.Fatalf("Lookup failed: '%v'", err)
+		}
+		if *addr != known.Address {
+			t.Error("Lookup returned the wrong address")
+		}
+	})
+
+	t.Run("Package functions use DefaultSAMAddress", func(t *testing.T) {
+		old := DefaultSAMAddress
+		DefaultSAMAddress = "unix://" + path
+		defer func() { DefaultSAMAddress = old }()
+
+		if _, err := NewDestination(); err != nil {
+			t.Errorf("NewDestination failed: '%v'", err)
+		}
+		if _, err := Lookup("known.i2p"); err != nil {
+			t.Errorf("Lookup failed: '%v'", err)
+		}
+	})
+
+	t.Run("Dial function", func(t *testing.T) {
+		var network, address string
+		dial := func(ctx context.Context, n, a string) (net.Conn, error) {
+			network, address = n, a
+			var d net.Dialer
+			return d.DialContext(ctx, n, a)
+		}
+		client := NewSAMClient(WithSAMAddress("unix://"+path), WithDialFunc(dial))
+		if _, err := client.Lookup("known.i2p"); err != nil {
+			t.Fatalf("Lookup failed: '%v'", err)
+		}
+		if network != "unix" || address != path {
+			t.Errorf("Dial function called with %s %s, want unix %s", network, address, path)
+		}
+	})
+
+	t.Run("Missing socket", func(t *testing.T) {
+		client := NewSAMClient(WithSAMAddress("unix://" + filepath.Join(t.TempDir(), "missing.sock")))
+		if _, err := client.Lookup("known.i2p"); err == nil {
+			t.Error("Expected an error for a missing socket")
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package i2pkeys
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxReplySize bounds a single SAM reply line. Replies carrying keys are the
+// largest and stay well below it.
+const maxReplySize = 64 * 1024
+
+// Errors for SAM RESULT codes, usable with errors.Is on errors returned by
+// SAMClient. The *SAMError carrying the code and message can be recovered
+// with errors.As.
+var (
+	ErrSAMProtocol    = errors.New("malformed SAM reply")
+	ErrSAMVersion     = errors.New("no common SAM version")
+	ErrNameNotFound   = errors.New("name not found")
+	ErrInvalidKey     = errors.New("invalid key")
+	ErrInvalidID      = errors.New("invalid session ID")
+	ErrDuplicatedID   = errors.New("duplicated session ID")
+	ErrDuplicatedDest = errors.New("duplicated destination")
+	ErrPeerNotFound   = errors.New("peer not found")
+	ErrCantReachPeer  = errors.New("cannot reach peer")
+	ErrSAMTimeout     = errors.New("SAM bridge timeout")
+	ErrI2PError       = errors.New("I2P error")
+)
+
+var samResults = map[string]error{
+	"NOVERSION":       ErrSAMVersion,
+	"KEY_NOT_FOUND":   ErrNameNotFound,
+	"INVALID_KEY":     ErrInvalidKey,
+	"INVALID_ID":      ErrInvalidID,
+	"DUPLICATED_ID":   ErrDuplicatedID,
+	"DUPLICATED_DEST": ErrDuplicatedDest,
+	"PEER_NOT_FOUND":  ErrPeerNotFound,
+	"CANT_REACH_PEER": ErrCantReachPeer,
+	"TIMEOUT":         ErrSAMTimeout,
+	"I2P_ERROR":       ErrI2PError,
+}
+
+// SAMError is a reply from the SAM bridge with a RESULT other than OK.
+type SAMError struct {
+	Reply   string // reply topic and type, such as "NAMING REPLY"
+	Result  string // RESULT value, such as "KEY_NOT_FOUND"
+	Message string // optional MESSAGE value
+}
+
+func (e *SAMError) Error() string {
+	msg := e.Reply + " " + e.Result
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel error for the result code, or ErrI2PError for
+// codes without one.
+func (e *SAMError) Unwrap() error {
+	if err, ok := samResults[e.Result]; ok {
+		return err
+	}
+	return ErrI2PError
+}
+
+// samReply is a parsed SAM reply line: a topic, a type and key=value pairs.
+// Keys without a value, such as flags, map to the empty string.
+type samReply struct {
+	Topic  string
+	Type   string
+	Values map[string]string
+}
+
+// parseSAMReply tokenizes a reply line. Values may be double-quoted to
+// include spaces, with \" and \\ escapes inside quotes.
+func parseSAMReply(line string) (*samReply, error) {
+	tokens, err := tokenizeSAM(strings.TrimRight(line, "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 2 || strings.Contains(tokens[0], "=") || strings.Contains(tokens[1], "=") {
+		return nil, fmt.Errorf("%w: missing reply topic and type", ErrSAMProtocol)
+	}
+
+	r := &samReply{Topic: tokens[0], Type: tokens[1], Values: make(map[string]string)}
+	for _, tok := range tokens[2:] {
+		key, value, _ := strings.Cut(tok, "=")
+		if key == "" {
+			return nil, fmt.Errorf("%w: empty key", ErrSAMProtocol)
+		}
+		r.Values[key] = value
+	}
+	return r, nil
+}
+
+// tokenizeSAM splits a line on spaces, removing the quotes and escapes of
+// quoted sections.
+func tokenizeSAM(line string) ([]string, error) {
+	var (
+		tokens  []string
+		tok     strings.Builder
+		inToken bool
+		quoted  bool
+	)
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quoted && ch == '\\' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\\'):
+			i++
+			tok.WriteByte(line[i])
+		case ch == '"':
+			quoted = !quoted
+			inToken = true
+		case ch == ' ' && !quoted:
+			if inToken {
+				tokens = append(tokens, tok.String())
+				tok.Reset()
+				inToken = false
+			}
+		default:
+			tok.WriteByte(ch)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quote", ErrSAMProtocol)
+	}
+	if inToken {
+		tokens = append(tokens, tok.String())
+	}
+	return tokens, nil
+}
+
+// err returns a *SAMError if the reply has a RESULT other than OK.
+func (r *samReply) err() error {
+	result, ok := r.Values["RESULT"]
+	if !ok || result == "OK" {
+		return nil
+	}
+	return &SAMError{Reply: r.Topic + " " + r.Type, Result: result, Message: r.Values["MESSAGE"]}
+}
+
+// checkSAMValue rejects values that would break out of a command line.
+func checkSAMValue(name, value string) error {
+	if value == "" || strings.ContainsFunc(value, func(r rune) bool { return r <= ' ' || r == '"' || r == 0x7f }) {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
+
+// quoteSAMValue quotes a value for a command if it contains spaces, quotes,
+// backslashes or equals signs. Control characters cannot be sent at all.
+func quoteSAMValue(name, value string) (string, error) {
+	if strings.ContainsFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return "", fmt.Errorf("invalid %s: contains control characters", name)
+	}
+	if value != "" && !strings.ContainsAny(value, " \"\\=") {
+		return value, nil
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`, nil
+}
+
+// readSAMLine reads one newline-terminated line, however it is split across
+// reads, rejecting lines longer than maxReplySize.
+func readSAMLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxReplySize {
+			return "", fmt.Errorf("%w: reply longer than %d bytes", ErrSAMProtocol, maxReplySize)
+		}
+		switch err {
+		case nil:
+			return string(line), nil
+		case bufio.ErrBufferFull:
+			continue
+		default:
+			return "", err
+		}
+	}
+}
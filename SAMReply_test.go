@@ -0,0 +1,76 @@
+package i2pkeys
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ParseSAMReply(t *testing.T) {
+	cases := []struct {
+		line   string
+		topic  string
+		values map[string]string
+	}{
+		{"HELLO REPLY RESULT=OK VERSION=3.1\n", "HELLO", map[string]string{"RESULT": "OK", "VERSION": "3.1"}},
+		{"NAMING REPLY RESULT=KEY_NOT_FOUND NAME=x.i2p MESSAGE=\"not in the address book\"", "NAMING",
+			map[string]string{"RESULT": "KEY_NOT_FOUND", "NAME": "x.i2p", "MESSAGE": "not in the address book"}},
+		{`SESSION STATUS RESULT=I2P_ERROR MESSAGE="a \"quoted\" \\ path"`, "SESSION",
+			map[string]string{"RESULT": "I2P_ERROR", "MESSAGE": `a "quoted" \ path`}},
+		{"DEST REPLY  PUB=abc==  PRIV=def= FLAG\r\n", "DEST", map[string]string{"PUB": "abc==", "PRIV": "def=", "FLAG": ""}},
+	}
+	for _, tc := range cases {
+		reply, err := parseSAMReply(tc.line)
+		if err != nil {
+			t.Errorf("parseSAMReply(%q) failed: '%v'", tc.line, err)
+			continue
+		}
+		if reply.Topic != tc.topic || !reflect.DeepEqual(reply.Values, tc.values) {
+			t.Errorf("parseSAMReply(%q) = %s %v, want %s %v", tc.line, reply.Topic, reply.Values, tc.topic, tc.values)
+		}
+	}
+
+	for _, line := range []string{"", "HELLO", "HELLO REPLY MESSAGE=\"unterminated", "HELLO REPLY =value"} {
+		if _, err := parseSAMReply(line); !errors.Is(err, ErrSAMProtocol) {
+			t.Errorf("parseSAMReply(%q): expected ErrSAMProtocol, got %v", line, err)
+		}
+	}
+}
+
+func Test_SAMErrorMapping(t *testing.T) {
+	reply, err := parseSAMReply("NAMING REPLY RESULT=KEY_NOT_FOUND NAME=x.i2p")
+	if err != nil {
+		t.Fatalf("parseSAMReply failed: '%v'", err)
+	}
+	if err := reply.err(); !errors.Is(err, ErrNameNotFound) {
+		t.Errorf("Expected ErrNameNotFound, got %v", err)
+	}
+	reply, _ = parseSAMReply("STREAM STATUS RESULT=SOMETHING_NEW")
+	if err := reply.err(); !errors.Is(err, ErrI2PError) {
+		t.Errorf("Expected ErrI2PError for unknown result, got %v", err)
+	}
+	reply, _ = parseSAMReply("HELLO REPLY RESULT=OK VERSION=3.1")
+	if err := reply.err(); err != nil {
+		t.Errorf("Expected no error for RESULT=OK, got %v", err)
+	}
+}
+
+func Test_ReadSAMLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("first line\nsecond "+strings.Repeat("x", 100)+"\n"), 16)
+	for _, want := range []string{"first line\n", "second " + strings.Repeat("x", 100) + "\n"} {
+		line, err := readSAMLine(r)
+		if err != nil {
+			t.Fatalf("readSAMLine failed: '%v'", err)
+		}
+		if line != want {
+			t.Errorf("readSAMLine = %q, want %q", line, want)
+		}
+	}
+
+	long := bufio.NewReader(strings.NewReader(strings.Repeat("x", maxReplySize+1) + "\n"))
+	if _, err := readSAMLine(long); !errors.Is(err, ErrSAMProtocol) {
+		t.Errorf("Expected ErrSAMProtocol for an oversized line, got %v", err)
+	}
+}
@@ -1,45 +1,194 @@
 package i2pkeys
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 )
 
+// DefaultKeyBackups is the number of replaced key files StoreKeys keeps when
+// overwriting is allowed.
+const DefaultKeyBackups = 3
+
+// keyFilePerm is the mode of stored key files, which must not be readable by
+// other users.
+const keyFilePerm os.FileMode = 0o600
+
+var ErrKeysExist = errors.New("different keys already stored at path")
+
+// StoreOption configures StoreKeysWithOptions.
+type StoreOption func(*storeConfig)
+
+type storeConfig struct {
+	overwrite bool
+	backups   int
+}
+
+// WithOverwrite allows replacing a key file that holds different keys, and
+// rewriting one that holds the same keys in another format.
+func WithOverwrite(overwrite bool) StoreOption {
+	return func(c *storeConfig) {
+		c.overwrite = overwrite
+	}
+}
+
+// WithBackups sets how many replaced key files are kept as path.1 (newest)
+// through path.n (oldest). Zero disables backups.
+func WithBackups(n int) StoreOption {
+	return func(c *storeConfig) {
+		c.backups = max(n, 0)
+	}
+}
+
 // store keys in non standard format
 func StoreKeysIncompat(k I2PKeys, w io.Writer) error {
 	log.Debug("Storing keys")
 	_, err := io.WriteString(w, k.Address.Base64()+"\n"+k.Both)
 	if err != nil {
-		log.WithError(err).Error("Error writing keys")
+		log.Error("Error writing keys", "error", err)
 		return fmt.Errorf("error writing keys: %w", err)
 	}
-	log.WithField("keys", k).Debug("Keys stored successfully")
+	log.Debug("Keys stored successfully", "address", k.Address.Base32())
 	return nil
 }
 
+// StoreKeys writes keys to a file in the legacy format. It is equivalent to
+// StoreKeysWithOptions without options, so it refuses to replace a file
+// holding different keys.
 func StoreKeys(k I2PKeys, r string) error {
-	log.WithField("filename", r).Debug("Storing keys to file")
-	if _, err := os.Stat(r); err != nil {
-		if os.IsNotExist(err) {
-			log.WithField("filename", r).Debug("File does not exist, creating new file")
-			fi, err := os.Create(r)
-			if err != nil {
-				log.WithError(err).Error("Error creating file")
-				return err
+	return StoreKeysWithOptions(k, r)
+}
+
+// StoreKeysWithOptions writes keys to a file in the legacy format. The file is
+// replaced atomically and is only readable by its owner. A file already
+// holding these keys, in any format, is left alone so metadata such as a
+// versioned file's label is kept; WithOverwrite rewrites it in this format.
+// If it holds anything else, ErrKeysExist is returned unless WithOverwrite is
+// given. Whenever a file is replaced, the old one is kept as a numbered
+// backup.
+func StoreKeysWithOptions(k I2PKeys, r string, options ...StoreOption) error {
+	var buf bytes.Buffer
+	if err := StoreKeysIncompat(k, &buf); err != nil {
+		return err
+	}
+	return storeKeyData(k, r, buf.Bytes(), loadKeyData, options)
+}
+
+// loadKeyData reads keys in any unencrypted format LoadKeysIncompat
+// understands.
+func loadKeyData(data []byte) (I2PKeys, error) {
+	return LoadKeysIncompat(bytes.NewReader(data))
+}
+
+// storeKeyData atomically writes the encoded keys to r, applying the
+// overwrite and backup rules of StoreKeysWithOptions. Existing files are
+// decoded with load and compared with k.
+func storeKeyData(k I2PKeys, r string, data []byte, load func([]byte) (I2PKeys, error), options []StoreOption) error {
+	cfg := &storeConfig{backups: DefaultKeyBackups}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	log.Debug("Storing keys to file", "filename", r)
+
+	existing, err := os.ReadFile(r)
+	switch {
+	case os.IsNotExist(err):
+		log.Debug("File does not exist, creating new file", "filename", r)
+	case err != nil:
+		log.Error("Error reading existing file", "filename", r, "error", err)
+		return err
+	case bytes.Equal(existing, data):
+		log.Debug("File already holds these keys", "filename", r)
+		return os.Chmod(r, keyFilePerm)
+	default:
+		if !cfg.overwrite {
+			if stored, err := load(existing); err == nil && stored == k {
+				log.Debug("File already holds these keys in another format", "filename", r)
+				return os.Chmod(r, keyFilePerm)
 			}
-			defer fi.Close()
-			return StoreKeysIncompat(k, fi)
+			log.Error("Refusing to overwrite different keys", "filename", r)
+			return fmt.Errorf("%w: %s", ErrKeysExist, r)
+		}
+		if err := rotateBackups(r, cfg.backups); err != nil {
+			log.Error("Error backing up existing file", "filename", r, "error", err)
+			return fmt.Errorf("error backing up existing keys: %w", err)
+		}
+	}
+	return writeFileAtomic(r, data, keyFilePerm)
+}
+
+// rotateBackups shifts path.1 through path.n-1 up by one, discarding path.n,
+// and copies path to path.1. The original stays in place until it is
+// atomically replaced. Backups are only readable by their owner, whatever the
+// mode of the file they were taken from.
+func rotateBackups(path string, n int) error {
+	if n == 0 {
+		return nil
+	}
+	backup := func(i int) string { return path + "." + strconv.Itoa(i) }
+	if err := os.Remove(backup(n)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := n - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
 		}
-		// If stat failed for reasons other than file not existing, return the error
+	}
+	if err := os.Link(path, backup(1)); err == nil {
+		// The link shares the old file's inode and mode
+		return os.Chmod(backup(1), keyFilePerm)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
 		return err
 	}
-	// File exists - open in write mode to allow overwriting
-	fi, err := os.OpenFile(r, os.O_WRONLY|os.O_TRUNC, 0644)
+	return writeFileAtomic(backup(1), data, keyFilePerm)
+}
+
+// writeFileAtomic replaces path with data by writing a temporary file in the
+// same directory and renaming it over the original. The file and, where the
+// platform allows it, the directory are synced so the rename survives a crash.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
 	if err != nil {
-		log.WithError(err).Error("Error opening file")
+		log.Error("Error creating temporary file", "error", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
 		return err
 	}
-	defer fi.Close()
-	return StoreKeysIncompat(k, fi)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes a directory entry to disk. Not every platform can sync a
+// directory, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
 }
@@ -0,0 +1,205 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func Test_StoreKeysSafety(t *testing.T) {
+	var generations []I2PKeys
+	for i := 0; i < 5; i++ {
+		keys, err := NewLocalDestination()
+		if err != nil {
+			t.Fatalf("NewLocalDestination failed: %v", err)
+		}
+		generations = append(generations, *keys)
+	}
+	path := filepath.Join(t.TempDir(), "keys.dat")
+
+	if err := StoreKeys(generations[0], path); err != nil {
+		t.Fatalf("StoreKeys failed: '%v'", err)
+	}
+
+	t.Run("Permissions", func(t *testing.T) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Wrong file permissions. Got %o, want 600", perm)
+		}
+	})
+
+	t.Run("Same keys", func(t *testing.T) {
+		if err := StoreKeys(generations[0], path); err != nil {
+			t.Errorf("StoreKeys with identical keys failed: '%v'", err)
+		}
+	})
+
+	t.Run("Refuse overwrite", func(t *testing.T) {
+		if err := StoreKeys(generations[1], path); !errors.Is(err, ErrKeysExist) {
+			t.Errorf("Expected ErrKeysExist, got %v", err)
+		}
+		loaded, err := LoadKeys(path)
+		if err != nil {
+			t.Fatalf("LoadKeys failed: '%v'", err)
+		}
+		if loaded != generations[0] {
+			t.Error("Refused overwrite modified the stored keys")
+		}
+	})
+
+	t.Run("Backups", func(t *testing.T) {
+		for _, keys := range generations[1:] {
+			if err := StoreKeysWithOptions(keys, path, WithOverwrite(true), WithBackups(2)); err != nil {
+				t.Fatalf("StoreKeysWithOptions failed: '%v'", err)
+			}
+		}
+		want := map[string]I2PKeys{
+			path:        generations[4],
+			path + ".1": generations[3],
+			path + ".2": generations[2],
+		}
+		for file, keys := range want {
+			loaded, err := LoadKeys(file)
+			if err != nil {
+				t.Fatalf("LoadKeys(%s) failed: '%v'", file, err)
+			}
+			if loaded != keys {
+				t.Errorf("%s holds the wrong keys", filepath.Base(file))
+			}
+		}
+		if _, err := os.Stat(path + "." + strconv.Itoa(3)); !os.IsNotExist(err) {
+			t.Errorf("Expected only 2 backups, stat of third returned %v", err)
+		}
+	})
+
+	t.Run("All writers", func(t *testing.T) {
+		passphrase := []byte("passphrase")
+		writers := map[string]func(k I2PKeys, path string, options ...StoreOption) error{
+			"StoreKeysWithOptions": StoreKeysWithOptions,
+			"StorePrivateKeyFile":  StorePrivateKeyFile,
+			"StoreKeyFile": func(k I2PKeys, path string, options ...StoreOption) error {
+				return StoreKeyFile(k, path, "label", options...)
+			},
+			"StoreEncryptedKeys": func(k I2PKeys, path string, options ...StoreOption) error {
+				return StoreEncryptedKeys(k, path, passphrase, options...)
+			},
+		}
+		for name, store := range writers {
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "keys.dat")
+				if err := store(generations[0], path); err != nil {
+					t.Fatalf("Storing keys failed: '%v'", err)
+				}
+				original, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("ReadFile failed: %v", err)
+				}
+				if err := store(generations[0], path); err != nil {
+					t.Errorf("Storing the same keys failed: '%v'", err)
+				}
+				if again, err := os.ReadFile(path); err != nil || !bytes.Equal(again, original) {
+					t.Errorf("Storing the same keys rewrote the file: %v", err)
+				}
+				if err := store(generations[1], path); !errors.Is(err, ErrKeysExist) {
+					t.Errorf("Expected ErrKeysExist, got %v", err)
+				}
+				if err := store(generations[1], path, WithOverwrite(true)); err != nil {
+					t.Fatalf("Overwriting keys failed: '%v'", err)
+				}
+				backup, err := os.ReadFile(path + ".1")
+				if err != nil {
+					t.Fatalf("ReadFile failed: %v", err)
+				}
+				if !bytes.Equal(backup, original) {
+					t.Error("Replaced keys were not kept as a backup")
+				}
+			})
+		}
+	})
+
+	t.Run("Format change", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.dat")
+		if err := StoreKeyFile(generations[0], path, "primary"); err != nil {
+			t.Fatalf("StoreKeyFile failed: '%v'", err)
+		}
+		original, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if err := StoreKeys(generations[0], path); err != nil {
+			t.Errorf("StoreKeys with the same keys failed: '%v'", err)
+		}
+		if err := StorePrivateKeyFile(generations[0], path); err != nil {
+			t.Errorf("StorePrivateKeyFile with the same keys failed: '%v'", err)
+		}
+		if err := StoreEncryptedKeys(generations[0], path, []byte("passphrase")); !errors.Is(err, ErrKeysExist) {
+			t.Errorf("Expected ErrKeysExist for unencrypted keys, got %v", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !bytes.Equal(content, original) {
+			t.Error("Storing the same keys changed the versioned file")
+		}
+		// LoadKeyFile verifies the checksum
+		_, meta, err := LoadKeyFile(path)
+		if err != nil {
+			t.Fatalf("LoadKeyFile failed: '%v'", err)
+		}
+		if meta.Label != "primary" {
+			t.Errorf("Wrong label after storing the same keys: %q", meta.Label)
+		}
+
+		if err := StoreKeysWithOptions(generations[0], path, WithOverwrite(true)); err != nil {
+			t.Fatalf("StoreKeysWithOptions failed: '%v'", err)
+		}
+		backup, err := os.ReadFile(path + ".1")
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if !bytes.Equal(backup, original) {
+			t.Error("Versioned file was not kept as a backup")
+		}
+	})
+
+	t.Run("Backup permissions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.dat")
+		var legacy bytes.Buffer
+		if err := StoreKeysIncompat(generations[0], &legacy); err != nil {
+			t.Fatalf("StoreKeysIncompat failed: %v", err)
+		}
+		if err := os.WriteFile(path, legacy.Bytes(), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.Chmod(path, 0o644); err != nil {
+			t.Fatalf("Chmod failed: %v", err)
+		}
+		if err := StoreKeysWithOptions(generations[1], path, WithOverwrite(true)); err != nil {
+			t.Fatalf("StoreKeysWithOptions failed: '%v'", err)
+		}
+		info, err := os.Stat(path + ".1")
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("Wrong backup permissions. Got %o, want 600", perm)
+		}
+	})
+
+	t.Run("No temporary files", func(t *testing.T) {
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		if len(entries) != 3 {
+			t.Errorf("Expected key file and 2 backups, found %d entries", len(entries))
+		}
+	})
+}
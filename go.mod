@@ -2,9 +2,10 @@ module github.com/go-i2p/i2pkeys
 
 go 1.23.3
 
-require github.com/go-i2p/logger v0.0.0-20241123010126-3050657e5d0c
-
 require (
-	github.com/sirupsen/logrus v1.9.3 // indirect
-	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+	filippo.io/edwards25519 v1.1.0
+	github.com/go-i2p/logger v0.0.0-20241123010126-3050657e5d0c
+	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sys v0.28.0
 )
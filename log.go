@@ -1,14 +1,104 @@
 package i2pkeys
 
 import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
 	"github.com/go-i2p/logger"
+	"github.com/sirupsen/logrus"
 )
 
-var log *logger.Logger
+// Logger receives the package's log output. Messages carry structured
+// key/value pairs in the style of log/slog, using the keys "filename",
+// "address", "name", "sam" and "error" consistently, so a *slog.Logger can be
+// used directly.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// log forwards to the logger set with SetLogger.
+var log packageLogger
+
+var activeLogger atomic.Pointer[loggerHolder]
+
+// loggerHolder lets loggers of different dynamic types share one
+// atomic.Pointer.
+type loggerHolder struct {
+	Logger
+}
+
+// SetLogger routes the package's log output to l. Passing nil restores the
+// default go-i2p logger, which is configured with the DEBUG_I2P environment
+// variable. Use NopLogger to silence the package.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = goI2PLogger{logger.GetGoI2PLogger()}
+	}
+	activeLogger.Store(&loggerHolder{l})
+}
+
+// NopLogger discards all log output.
+var NopLogger Logger = nopLogger{}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+type packageLogger struct{}
+
+func (packageLogger) Debug(msg string, args ...any) { activeLogger.Load().Debug(msg, args...) }
+func (packageLogger) Info(msg string, args ...any)  { activeLogger.Load().Info(msg, args...) }
+func (packageLogger) Warn(msg string, args ...any)  { activeLogger.Load().Warn(msg, args...) }
+func (packageLogger) Error(msg string, args ...any) { activeLogger.Load().Error(msg, args...) }
+
+// goI2PLogger adapts the go-i2p logrus logger to Logger, turning key/value
+// pairs into logrus fields.
+type goI2PLogger struct {
+	l *logger.Logger
+}
+
+func (g goI2PLogger) Debug(msg string, args ...any) { g.entry(args).Debug(msg) }
+func (g goI2PLogger) Info(msg string, args ...any)  { g.entry(args).Info(msg) }
+func (g goI2PLogger) Warn(msg string, args ...any)  { g.entry(args).Warn(msg) }
+func (g goI2PLogger) Error(msg string, args ...any) { g.entry(args).Error(msg) }
+
+func (g goI2PLogger) entry(args []any) *logrus.Entry {
+	// Collect the pairs through a slog.Record so they are interpreted, and
+	// LogValuers such as I2PKeys resolved, exactly as slog would
+	r := slog.NewRecord(time.Time{}, slog.LevelDebug, "", 0)
+	r.Add(args...)
+	fields := make(logrus.Fields, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields[a.Key] = attrValue(a.Value)
+		return true
+	})
+	return g.l.Logger.WithFields(fields)
+}
+
+// attrValue converts a slog value to a plain Go value, turning groups into
+// maps.
+func attrValue(v slog.Value) any {
+	v = v.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	group := make(map[string]any)
+	for _, a := range v.Group() {
+		group[a.Key] = attrValue(a.Value)
+	}
+	return group
+}
 
 func InitializeI2PKeysLogger() {
 	logger.InitializeGoI2PLogger()
-	log = logger.GetGoI2PLogger()
+	SetLogger(nil)
 }
 
 // GetI2PKeysLogger returns the initialized logger
@@ -0,0 +1,72 @@
+package i2pkeys
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func Test_SetLogger(t *testing.T) {
+	t.Cleanup(func() { SetLogger(nil) })
+	keys, err := NewLocalDestination()
+	if err != nil {
+		t.Fatalf("NewLocalDestination failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.dat")
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		if err := StoreKeys(*keys, path); err != nil {
+			t.Fatalf("StoreKeys failed: '%v'", err)
+		}
+
+		var found bool
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("Invalid log line %q: %v", line, err)
+			}
+			if record["msg"] == "Storing keys to file" {
+				found = record["filename"] == path
+			}
+		}
+		if !found {
+			t.Errorf("Expected a structured storage record, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("NopLogger", func(t *testing.T) {
+		SetLogger(NopLogger)
+		if _, err := LoadKeys(path); err != nil {
+			t.Fatalf("LoadKeys failed: '%v'", err)
+		}
+	})
+
+	t.Run("go-i2p adapter", func(t *testing.T) {
+		var buf bytes.Buffer
+		l := logger.New()
+		l.SetOutput(&buf)
+		l.SetLevel(logrus.DebugLevel)
+		l.SetFormatter(&logrus.JSONFormatter{})
+		SetLogger(goI2PLogger{l})
+
+		log.Debug("Loaded keys", "filename", path, "keys", *keys)
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", buf.String(), err)
+		}
+		if record["filename"] != path {
+			t.Errorf("Expected filename field, got %v", record)
+		}
+		if strings.Contains(buf.String(), keys.Both[len(keys.Both)-40:]) {
+			t.Error("Log output leaked private keys")
+		}
+	})
+}
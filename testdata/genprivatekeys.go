@@ -0,0 +1,79 @@
+//go:build ignore
+
+// Assembles private key files byte by byte from the I2P common structures
+// specification, without the i2pkeys package. Run from testdata with
+// "go run genprivatekeys.go".
+package main
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"time"
+)
+
+var p, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7EDEE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3BE39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF6955817183995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF", 16)
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func random(n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	must(err)
+	return b
+}
+
+func elgamal() (pub, priv []byte) {
+	x, err := rand.Int(rand.Reader, new(big.Int).Sub(p, big.NewInt(2)))
+	must(err)
+	x.Add(x, big.NewInt(1))
+	y := new(big.Int).Exp(big.NewInt(2), x, p)
+	return y.FillBytes(make([]byte, 256)), x.FillBytes(make([]byte, 256))
+}
+
+func build(encType uint16, offline bool) []byte {
+	var encPub, encPriv []byte
+	if encType == 0 {
+		encPub, encPriv = elgamal()
+	} else {
+		k, err := ecdh.X25519().GenerateKey(rand.Reader)
+		must(err)
+		encPub, encPriv = k.PublicKey().Bytes(), k.Bytes()
+	}
+	sigPub, sigPriv, err := ed25519.GenerateKey(rand.Reader)
+	must(err)
+
+	var out []byte
+	out = append(out, encPub...)
+	out = append(out, random(256-len(encPub))...)
+	out = append(out, random(128-32)...)
+	out = append(out, sigPub...)
+	out = append(out, 5, 0, 4, 0, 7)
+	out = binary.BigEndian.AppendUint16(out, encType)
+	out = append(out, encPriv...)
+	if !offline {
+		return append(out, sigPriv.Seed()...)
+	}
+	out = append(out, make([]byte, 32)...)
+	tPub, tPriv, err := ed25519.GenerateKey(rand.Reader)
+	must(err)
+	signed := binary.BigEndian.AppendUint32(nil, uint32(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Unix()))
+	signed = binary.BigEndian.AppendUint16(signed, 7)
+	signed = append(signed, tPub...)
+	out = append(out, signed...)
+	out = append(out, ed25519.Sign(sigPriv, signed)...)
+	return append(out, tPriv.Seed()...)
+}
+
+func main() {
+	must(os.WriteFile("ed25519-elgamal.dat", build(0, false), 0o644))
+	must(os.WriteFile("ed25519-x25519.dat", build(4, false), 0o644))
+	must(os.WriteFile("ed25519-elgamal-offline.dat", build(0, true), 0o644))
+}